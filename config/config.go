@@ -0,0 +1,277 @@
+// Package config creates a driver.Connector from a YAML or JSON document describing the database and
+// the store its credentials come from:
+//
+//	driver: pgx
+//	host: db.example.com
+//	port: 5432
+//	db: app
+//	opts:
+//	  sslmode: require
+//	retries: 3
+//	refresh_before: 1m
+//	store:
+//	  type: vault
+//	  address: https://vault.example.com:8200
+//	  auth:
+//	    type: kubernetes
+//	    role: app
+//	  credentials:
+//	    type: database
+//	    role: app
+//
+// The store is created with the factory registered with store.Register under its type, so the
+// package of the store has to be imported. The other keys of the store are passed to the factory as
+// parameters. A nested section is flattened into parameters prefixed with its key and an underscore,
+// and its type becomes the parameter named after the key, so the store above gets the parameters
+// auth=kubernetes, auth_role=app, credentials=database and credentials_role=app. See the
+// NewStoreFromURL function of each store for the parameters it accepts.
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+	"github.com/davepgreene/go-db-credential-refresh/store"
+)
+
+// typeKey is the key holding the type of the store and of its nested sections.
+const typeKey = "type"
+
+var (
+	// ErrMissingField is returned in a FieldError when a required field isn't set.
+	ErrMissingField = errors.New("field is required")
+	// ErrUnknownStore is returned in a FieldError when no store is registered under the store type.
+	ErrUnknownStore = errors.New("unknown store")
+	// ErrInvalidValue is returned in a FieldError when a store field holds a list.
+	ErrInvalidValue = errors.New("value must be a scalar or a mapping")
+)
+
+// FieldError reports a missing or invalid field. Path is the dotted path of the field in the
+// document, e.g. store.credentials.role.
+type FieldError struct {
+	Path string
+	Err  error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Config is a configuration document.
+type Config struct {
+	// Driver is the name the database driver was registered with in driver.Register.
+	Driver string `yaml:"driver"`
+	// Host, Port, DB, Opts and Retries are copied to the driver.Config.
+	Host    string            `yaml:"host"`
+	Port    int               `yaml:"port"`
+	DB      string            `yaml:"db"`
+	Opts    map[string]string `yaml:"opts"`
+	Retries int               `yaml:"retries"`
+	// RefreshBefore is a duration like 1m parsed with time.ParseDuration.
+	RefreshBefore string `yaml:"refresh_before"`
+	// Store describes the store. Its type is the name the store was registered with in store.Register.
+	Store map[string]any `yaml:"store"`
+}
+
+// Load reads the configuration document at path and creates a Connector from it.
+func Load(path string) (*driver.Connector, error) {
+	cfg, err := Read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg.Connector(context.Background())
+}
+
+// Read reads the configuration document at path. JSON documents are read as YAML, which they are a
+// subset of.
+func Read(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Parse parses a configuration document. Unknown fields are an error.
+func Parse(data []byte) (*Config, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	cfg := &Config{}
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// Connector validates the configuration and creates a Connector from it. Missing or invalid fields,
+// including the store parameters reported by the store factory, are returned as a *FieldError.
+func (c *Config) Connector(ctx context.Context) (*driver.Connector, error) {
+	driverCfg, err := c.driverConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	name, params, paths, err := c.storeParams()
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := store.OpenMap(ctx, name, params)
+	if err != nil {
+		var paramErr *store.ParamError
+		if errors.As(err, &paramErr) {
+			return nil, &FieldError{Path: paramPath(paths, paramErr.Param), Err: paramErr.Err}
+		}
+
+		return nil, err
+	}
+
+	conn, err := driver.NewConnector(s, c.Driver, driverCfg)
+	if err != nil {
+		if closer, ok := s.(io.Closer); ok {
+			_ = closer.Close()
+		}
+
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func (c *Config) driverConfig() (*driver.Config, error) {
+	if c.Driver == "" {
+		return nil, &FieldError{Path: "driver", Err: ErrMissingField}
+	}
+
+	if _, err := driver.CreateDriver(c.Driver); err != nil {
+		return nil, &FieldError{Path: "driver", Err: err}
+	}
+
+	cfg := &driver.Config{
+		Host:    c.Host,
+		Port:    c.Port,
+		DB:      c.DB,
+		Opts:    c.Opts,
+		Retries: c.Retries,
+	}
+
+	if c.RefreshBefore != "" {
+		d, err := time.ParseDuration(c.RefreshBefore)
+		if err != nil {
+			return nil, &FieldError{Path: "refresh_before", Err: err}
+		}
+
+		cfg.RefreshBefore = d
+	}
+
+	return cfg, nil
+}
+
+// storeParams flattens the store section into the name of the store and its parameters. paths maps
+// each parameter, and the prefix of each nested section, to its path in the document.
+func (c *Config) storeParams() (string, map[string]string, map[string]string, error) {
+	if c.Store == nil {
+		return "", nil, nil, &FieldError{Path: "store", Err: ErrMissingField}
+	}
+
+	name, ok := c.Store[typeKey].(string)
+	if !ok || name == "" {
+		return "", nil, nil, &FieldError{Path: "store." + typeKey, Err: ErrMissingField}
+	}
+
+	if !slices.Contains(store.Stores(), name) {
+		return "", nil, nil, &FieldError{Path: "store." + typeKey, Err: fmt.Errorf("%w %q", ErrUnknownStore, name)}
+	}
+
+	params := make(map[string]string)
+	paths := make(map[string]string)
+
+	for k, v := range c.Store {
+		if k == typeKey {
+			continue
+		}
+
+		if err := flatten(params, paths, k, "store."+k, v); err != nil {
+			return "", nil, nil, err
+		}
+	}
+
+	return name, params, paths, nil
+}
+
+func flatten(params, paths map[string]string, param, path string, v any) error {
+	switch v := v.(type) {
+	case map[string]any:
+		paths[param+"_"] = path
+
+		for k, sub := range v {
+			if k == typeKey {
+				if err := flatten(params, paths, param, path+"."+k, sub); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			if err := flatten(params, paths, param+"_"+k, path+"."+k, sub); err != nil {
+				return err
+			}
+		}
+	case []any:
+		return &FieldError{Path: path, Err: ErrInvalidValue}
+	case nil:
+	default:
+		params[param] = fmt.Sprint(v)
+		paths[param] = path
+	}
+
+	return nil
+}
+
+// paramPath finds the path in the document of a store parameter. Parameters that aren't in the
+// document, like missing ones, are placed in the longest section they are prefixed with. Without one
+// the part of the parameter before its first underscore is taken to be the section.
+func paramPath(paths map[string]string, param string) string {
+	if path, ok := paths[param]; ok {
+		return path
+	}
+
+	prefixes := make([]string, 0, len(paths))
+	for p := range paths {
+		if strings.HasSuffix(p, "_") && strings.HasPrefix(param, p) {
+			prefixes = append(prefixes, p)
+		}
+	}
+
+	if len(prefixes) > 0 {
+		sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+
+		return paths[prefixes[0]] + "." + strings.TrimPrefix(param, prefixes[0])
+	}
+
+	return "store." + strings.Replace(param, "_", ".", 1)
+}
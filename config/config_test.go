@@ -0,0 +1,279 @@
+package config
+
+import (
+	"context"
+	sqldriver "database/sql/driver"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+	"github.com/davepgreene/go-db-credential-refresh/store"
+)
+
+const testName = "configtest"
+
+var errTestDriver = errors.New("test driver doesn't connect")
+
+type testDriver struct{}
+
+func (testDriver) Open(_ string) (sqldriver.Conn, error) {
+	return nil, errTestDriver
+}
+
+type testStore struct {
+	params url.Values
+}
+
+func (s *testStore) Get(_ context.Context) (driver.Credentials, error) {
+	return &store.Credential{Username: s.params.Get("credentials_username"), Password: "password"}, nil
+}
+
+func (s *testStore) Refresh(ctx context.Context) (driver.Credentials, error) {
+	return s.Get(ctx)
+}
+
+//nolint:gochecknoglobals
+var lastParams url.Values
+
+func init() { //nolint:gochecknoinits
+	if err := driver.Register(testName, func() *driver.Driver {
+		return &driver.Driver{
+			Driver:    testDriver{},
+			Formatter: driver.PgFormatter,
+			AuthError: driver.PostgreSQLAuthError,
+		}
+	}); err != nil {
+		panic(err)
+	}
+
+	if err := store.Register(testName, func(_ context.Context, u *url.URL) (driver.Store, error) {
+		q := u.Query()
+		lastParams = q
+
+		if q.Get("credentials_username") == "" {
+			return nil, &store.ParamError{Store: testName, Param: "credentials_username", Err: store.ErrMissingParam}
+		}
+
+		return &testStore{params: q}, nil
+	}); err != nil {
+		panic(err)
+	}
+}
+
+func writeConfig(t *testing.T, name, data string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	testCases := []struct {
+		description string
+		name        string
+		data        string
+	}{
+		{
+			description: "yaml",
+			name:        "config.yaml",
+			data: `
+driver: configtest
+host: localhost
+port: 5432
+db: app
+opts:
+  sslmode: disable
+retries: 3
+refresh_before: 1m
+store:
+  type: configtest
+  address: http://localhost:8200
+  auth:
+    type: kubernetes
+    role: app
+  credentials:
+    type: database
+    username: app
+    ttl: 60
+`,
+		},
+		{
+			description: "json",
+			name:        "config.json",
+			data: `{
+  "driver": "configtest",
+  "host": "localhost",
+  "port": 5432,
+  "db": "app",
+  "opts": {"sslmode": "disable"},
+  "retries": 3,
+  "refresh_before": "1m",
+  "store": {
+    "type": "configtest",
+    "address": "http://localhost:8200",
+    "auth": {"type": "kubernetes", "role": "app"},
+    "credentials": {"type": "database", "username": "app", "ttl": 60}
+  }
+}`,
+		},
+	}
+
+	expected := url.Values{
+		"address":              {"http://localhost:8200"},
+		"auth":                 {"kubernetes"},
+		"auth_role":            {"app"},
+		"credentials":          {"database"},
+		"credentials_username": {"app"},
+		"credentials_ttl":      {"60"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			c, err := Load(writeConfig(t, tc.name, tc.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			if !reflect.DeepEqual(lastParams, expected) {
+				t.Fatalf("expected '%v' but got '%v' instead", expected, lastParams)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse([]byte("driver: configtest\nport: 3306\nrefresh_before: 30s\nstore:\n  type: configtest\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	driverCfg, err := cfg.driverConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if driverCfg.Port != 3306 || driverCfg.RefreshBefore != 30*time.Second {
+		t.Fatalf("expected '%v' but got '%v' instead", 30*time.Second, driverCfg.RefreshBefore)
+	}
+
+	if _, err := Parse([]byte("driver: configtest\nhots: localhost\n")); err == nil || !strings.Contains(err.Error(), "hots") {
+		t.Fatalf("expected an unknown field error but got '%v' instead", err)
+	}
+}
+
+func TestConnectorReportsFieldPaths(t *testing.T) {
+	testCases := []struct {
+		description string
+		data        string
+		path        string
+		err         error
+	}{
+		{
+			description: "missing driver",
+			data:        "store:\n  type: configtest\n",
+			path:        "driver",
+			err:         ErrMissingField,
+		},
+		{
+			description: "unknown driver",
+			data:        "driver: oracle\nstore:\n  type: configtest\n",
+			path:        "driver",
+		},
+		{
+			description: "invalid refresh_before",
+			data:        "driver: configtest\nrefresh_before: soon\nstore:\n  type: configtest\n",
+			path:        "refresh_before",
+		},
+		{
+			description: "missing store",
+			data:        "driver: configtest\n",
+			path:        "store",
+			err:         ErrMissingField,
+		},
+		{
+			description: "missing store type",
+			data:        "driver: configtest\nstore:\n  address: localhost\n",
+			path:        "store.type",
+			err:         ErrMissingField,
+		},
+		{
+			description: "unknown store type",
+			data:        "driver: configtest\nstore:\n  type: missing\n",
+			path:        "store.type",
+			err:         ErrUnknownStore,
+		},
+		{
+			description: "list value",
+			data:        "driver: configtest\nstore:\n  type: configtest\n  auth:\n    role: [a, b]\n",
+			path:        "store.auth.role",
+			err:         ErrInvalidValue,
+		},
+		{
+			description: "missing parameter in section",
+			data:        "driver: configtest\nstore:\n  type: configtest\n  credentials:\n    type: database\n",
+			path:        "store.credentials.username",
+			err:         store.ErrMissingParam,
+		},
+		{
+			description: "missing section",
+			data:        "driver: configtest\nstore:\n  type: configtest\n",
+			path:        "store.credentials.username",
+			err:         store.ErrMissingParam,
+		},
+		{
+			description: "empty parameter",
+			data:        "driver: configtest\nstore:\n  type: configtest\n  credentials_username: \"\"\n",
+			path:        "store.credentials_username",
+			err:         store.ErrMissingParam,
+		},
+		{
+			description: "unknown parameter",
+			data:        "driver: configtest\nstore:\n  type: static\n  username: app\n  pasword: secret\n",
+			path:        "store.pasword",
+			err:         store.ErrUnknownParam,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg, err := Parse([]byte(tc.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = cfg.Connector(context.Background())
+
+			var fieldErr *FieldError
+			if !errors.As(err, &fieldErr) {
+				t.Fatalf("expected '%T' but got '%v' instead", fieldErr, err)
+			}
+
+			if fieldErr.Path != tc.path {
+				t.Fatalf("expected '%s' but got '%s' instead", tc.path, fieldErr.Path)
+			}
+
+			if tc.err != nil && !errors.Is(err, tc.err) {
+				t.Fatalf("expected '%v' but got '%v' instead", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestFieldError(t *testing.T) {
+	err := error(&FieldError{Path: "store.credentials.role", Err: store.ErrMissingParam})
+
+	if expected := "store.credentials.role: parameter is required"; err.Error() != expected {
+		t.Fatalf("expected '%s' but got '%s' instead", expected, err.Error())
+	}
+}
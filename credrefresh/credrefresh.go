@@ -0,0 +1,110 @@
+// Package credrefresh registers a database/sql driver named credrefresh so the Connector can be used
+// by code that only accepts a driver name and a DSN, like migration tools and ORMs configured by
+// URL:
+//
+//	import _ "github.com/davepgreene/go-db-credential-refresh/credrefresh"
+//
+//	db, err := sql.Open("credrefresh", "credrefresh+pgx://localhost:5432/app?store=vault&vault_role=app")
+//
+// The store is created with the factory registered with store.Register. See DSN for the format.
+package credrefresh
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"io"
+	"sync"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+	"github.com/davepgreene/go-db-credential-refresh/store"
+)
+
+// DriverName is the name the driver is registered with in database/sql.
+const DriverName = Scheme
+
+func init() { //nolint:gochecknoinits
+	sql.Register(DriverName, &Driver{})
+}
+
+// Driver is a database/sql driver that connects through a driver.Connector built from a DSN.
+type Driver struct {
+	mu sync.Mutex
+	// connectors are reused by Open, which would otherwise create a new store for every connection.
+	connectors map[string]sqldriver.Connector
+}
+
+var (
+	_ sqldriver.Driver        = (*Driver)(nil)
+	_ sqldriver.DriverContext = (*Driver)(nil)
+)
+
+// Open implements the driver.Driver interface. database/sql only calls it for drivers that don't
+// implement driver.DriverContext so it is only used when the Driver is called directly.
+func (d *Driver) Open(dsn string) (sqldriver.Conn, error) {
+	d.mu.Lock()
+
+	c, ok := d.connectors[dsn]
+	if !ok {
+		var err error
+		if c, err = d.OpenConnector(dsn); err != nil {
+			d.mu.Unlock()
+
+			return nil, err
+		}
+
+		if d.connectors == nil {
+			d.connectors = make(map[string]sqldriver.Connector)
+		}
+
+		d.connectors[dsn] = c
+	}
+	d.mu.Unlock()
+
+	return c.Connect(context.Background())
+}
+
+// OpenConnector implements the driver.DriverContext interface.
+func (d *Driver) OpenConnector(dsn string) (sqldriver.Connector, error) {
+	c, err := NewConnector(context.Background(), dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &connector{Connector: c, driver: d}, nil
+}
+
+// NewConnector creates a driver.Connector from a credrefresh DSN.
+func NewConnector(ctx context.Context, dsn string) (*driver.Connector, error) {
+	parsed, err := ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := store.OpenURL(ctx, parsed.Store)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := driver.NewConnector(s, parsed.DriverName, parsed.Config)
+	if err != nil {
+		if closer, ok := s.(io.Closer); ok {
+			_ = closer.Close()
+		}
+
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// connector reports the credrefresh Driver as its driver so sql.DB.Driver returns it.
+type connector struct {
+	*driver.Connector
+	driver *Driver
+}
+
+// Driver implements the driver.Connector interface.
+func (c *connector) Driver() sqldriver.Driver {
+	return c.driver
+}
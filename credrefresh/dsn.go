@@ -0,0 +1,120 @@
+package credrefresh
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+)
+
+// Scheme is the scheme of credrefresh DSNs. It is followed by a plus sign and the name of the
+// driver registered with driver.Register, e.g. credrefresh+pgx://.
+const Scheme = "credrefresh"
+
+// Query parameters the DSN reserves for itself. Any other parameter prefixed with the name of the
+// store and an underscore is passed to the store without the prefix, and the rest are passed to the
+// database driver as Config.Opts.
+const (
+	ParamStore         = "store"
+	ParamRetries       = "retries"
+	ParamRefreshBefore = "refresh_before"
+)
+
+var (
+	ErrInvalidScheme = errors.New("dsn scheme must be " + Scheme + "+<driver>")
+	ErrMissingStore  = errors.New("dsn must set the " + ParamStore + " parameter")
+)
+
+type errInvalidParam struct {
+	param string
+	err   error
+}
+
+func (e errInvalidParam) Error() string {
+	return fmt.Sprintf("invalid dsn parameter %s: %v", e.param, e.err)
+}
+
+func (e errInvalidParam) Unwrap() error {
+	return e.err
+}
+
+// DSN is a parsed credrefresh DSN such as
+//
+//	credrefresh+pgx://db.example.com:5432/app?store=vault&vault_role=app&sslmode=require
+//
+// which connects to the app database on db.example.com with the pgx driver and credentials from the
+// store registered as vault, which is given the parameter role=app.
+type DSN struct {
+	// DriverName is the name the database driver was registered with.
+	DriverName string
+	// Config is the Connector configuration.
+	Config *driver.Config
+	// Store is passed to store.OpenURL to create the store. Its scheme is the name of the store.
+	Store *url.URL
+}
+
+// ParseDSN parses a credrefresh DSN.
+func ParseDSN(dsn string) (*DSN, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	scheme, driverName, ok := strings.Cut(u.Scheme, "+")
+	if !ok || scheme != Scheme || driverName == "" {
+		return nil, ErrInvalidScheme
+	}
+
+	cfg := &driver.Config{
+		Host: u.Hostname(),
+		DB:   strings.TrimPrefix(u.Path, "/"),
+	}
+
+	if p := u.Port(); p != "" {
+		if cfg.Port, err = strconv.Atoi(p); err != nil {
+			return nil, errInvalidParam{"port", err}
+		}
+	}
+
+	query := u.Query()
+
+	storeName := query.Get(ParamStore)
+	if storeName == "" {
+		return nil, ErrMissingStore
+	}
+
+	storeParams := url.Values{}
+	prefix := storeName + "_"
+
+	for k, v := range query {
+		switch {
+		case k == ParamStore:
+		case k == ParamRetries:
+			if cfg.Retries, err = strconv.Atoi(query.Get(k)); err != nil {
+				return nil, errInvalidParam{k, err}
+			}
+		case k == ParamRefreshBefore:
+			if cfg.RefreshBefore, err = time.ParseDuration(query.Get(k)); err != nil {
+				return nil, errInvalidParam{k, err}
+			}
+		case strings.HasPrefix(k, prefix):
+			storeParams[strings.TrimPrefix(k, prefix)] = v
+		default:
+			if cfg.Opts == nil {
+				cfg.Opts = make(map[string]string)
+			}
+
+			cfg.Opts[k] = query.Get(k)
+		}
+	}
+
+	return &DSN{
+		DriverName: driverName,
+		Config:     cfg,
+		Store:      &url.URL{Scheme: storeName, RawQuery: storeParams.Encode()},
+	}, nil
+}
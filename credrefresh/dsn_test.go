@@ -0,0 +1,117 @@
+package credrefresh
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+)
+
+func TestParseDSN(t *testing.T) {
+	testCases := []struct {
+		description string
+		dsn         string
+		expected    *DSN
+		expectedErr error
+	}{
+		{
+			description: "full dsn",
+			dsn: "credrefresh+pgx://db.example.com:5432/app?store=vault&vault_role=app&vault_mount=database" +
+				"&sslmode=require&retries=3&refresh_before=2m",
+			expected: &DSN{
+				DriverName: "pgx",
+				Config: &driver.Config{
+					Host:          "db.example.com",
+					Port:          5432,
+					DB:            "app",
+					Retries:       3,
+					RefreshBefore: 2 * time.Minute,
+					Opts:          map[string]string{"sslmode": "require"},
+				},
+			},
+		},
+		{
+			description: "minimal dsn",
+			dsn:         "credrefresh+mysql://localhost?store=static",
+			expected: &DSN{
+				DriverName: "mysql",
+				Config: &driver.Config{
+					Host: "localhost",
+				},
+			},
+		},
+		{
+			description: "wrong scheme",
+			dsn:         "postgres://localhost:5432/app?store=vault",
+			expectedErr: ErrInvalidScheme,
+		},
+		{
+			description: "missing driver",
+			dsn:         "credrefresh://localhost:5432/app?store=vault",
+			expectedErr: ErrInvalidScheme,
+		},
+		{
+			description: "missing store",
+			dsn:         "credrefresh+pgx://localhost:5432/app",
+			expectedErr: ErrMissingStore,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			dsn, err := ParseDSN(tc.dsn)
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Fatalf("expected '%v' but got '%v' instead", tc.expectedErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if dsn.DriverName != tc.expected.DriverName {
+				t.Fatalf("expected '%s' but got '%s' instead", tc.expected.DriverName, dsn.DriverName)
+			}
+
+			if !reflect.DeepEqual(dsn.Config, tc.expected.Config) {
+				t.Fatalf("expected '%+v' but got '%+v' instead", tc.expected.Config, dsn.Config)
+			}
+		})
+	}
+}
+
+func TestParseDSNPassesPrefixedParamsToStore(t *testing.T) {
+	dsn, err := ParseDSN("credrefresh+pgx://localhost/app?store=vault&vault_role=app&vault_mount=db&role=other")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if dsn.Store.Scheme != "vault" {
+		t.Fatalf("expected '%s' but got '%s' instead", "vault", dsn.Store.Scheme)
+	}
+
+	if expected := "mount=db&role=app"; dsn.Store.RawQuery != expected {
+		t.Fatalf("expected '%s' but got '%s' instead", expected, dsn.Store.RawQuery)
+	}
+
+	if expected := map[string]string{"role": "other"}; !reflect.DeepEqual(dsn.Config.Opts, expected) {
+		t.Fatalf("expected '%v' but got '%v' instead", expected, dsn.Config.Opts)
+	}
+}
+
+func TestParseDSNRejectsInvalidParams(t *testing.T) {
+	for _, dsn := range []string{
+		"credrefresh+pgx://localhost/app?store=vault&retries=many",
+		"credrefresh+pgx://localhost/app?store=vault&refresh_before=soon",
+	} {
+		var paramErr errInvalidParam
+		if _, err := ParseDSN(dsn); !errors.As(err, &paramErr) {
+			t.Fatalf("expected '%T' but got '%v' instead", paramErr, err)
+		}
+	}
+}
@@ -1,9 +1,14 @@
 package driver
 
 import (
-	"database/sql/driver"
 	"errors"
+	"regexp"
 	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	pgconnv4 "github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
 )
 
 // AuthError is a func to evaluate the DB-specific error string that indicates an authentication error.
@@ -14,14 +19,92 @@ const (
 	PgErrorText    = "password authentication failed for user"
 )
 
-// MySQLAuthError tests whether an error from MySQL is an authentication failure.
-var MySQLAuthError = errorTester(MysqlErrorText) //nolint:gochecknoglobals
+//nolint:gochecknoglobals
+var (
+	// pgAuthCodes are the SQLSTATE codes PostgreSQL reports for authentication failures.
+	// See https://www.postgresql.org/docs/current/errcodes-appendix.html
+	pgAuthCodes = map[string]bool{
+		"28000": true, // invalid_authorization_specification
+		"28P01": true, // invalid_password
+	}
+
+	// mysqlAuthCodes are the error numbers MySQL reports for authentication failures.
+	// See https://dev.mysql.com/doc/mysql-errors/8.0/en/server-error-reference.html
+	mysqlAuthCodes = map[uint16]bool{
+		1044: true, // ER_DBACCESS_DENIED_ERROR
+		1045: true, // ER_ACCESS_DENIED_ERROR
+		1698: true, // ER_ACCESS_DENIED_NO_PASSWORD_ERROR
+		1862: true, // ER_MUST_CHANGE_PASSWORD_LOGIN
+		3118: true, // ER_ACCOUNT_HAS_BEEN_LOCKED
+	}
+
+	// pgAuthMessages match the messages PostgreSQL reports when a user's password is wrong or the
+	// user has been revoked, e.g. when Vault drops the role at the end of its lease.
+	pgAuthMessages = []*regexp.Regexp{
+		regexp.MustCompile(regexp.QuoteMeta(PgErrorText)),
+		regexp.MustCompile(`role ".*" does not exist`),
+		regexp.MustCompile(`role ".*" is not permitted to log in`),
+	}
+
+	// mysqlAuthMessages match the messages MySQL reports when a user's password is wrong, has
+	// expired, or the user has been revoked.
+	mysqlAuthMessages = []*regexp.Regexp{
+		regexp.MustCompile(regexp.QuoteMeta(MysqlErrorText)),
+		regexp.MustCompile(`your password has expired`),
+	}
+)
+
+// MySQLAuthError tests whether an error from MySQL is an authentication failure, including users that
+// have expired or been revoked. Errors from go-sql-driver/mysql are classified by their error number
+// and anything else falls back to matching the error text.
+var MySQLAuthError AuthError = func(e error) bool { //nolint:gochecknoglobals
+	var myErr *mysql.MySQLError
+	if errors.As(e, &myErr) {
+		return mysqlAuthCodes[myErr.Number]
+	}
+
+	return matchesAny(e, mysqlAuthMessages)
+}
+
+// PostgreSQLAuthError tests whether an error from PostgreSQL is an authentication failure, including
+// roles that don't exist or can no longer log in. Errors from pgx (v4 and v5) and lib/pq are classified
+// by their SQLSTATE code and anything else falls back to matching the error text.
+var PostgreSQLAuthError AuthError = func(e error) bool { //nolint:gochecknoglobals
+	if code, ok := pgErrorCode(e); ok {
+		return pgAuthCodes[code]
+	}
+
+	return matchesAny(e, pgAuthMessages)
+}
 
-// PostgreSQLAuthError tests whether an error from PostgreSQL is an authentication failure.
-var PostgreSQLAuthError = errorTester(PgErrorText) //nolint:gochecknoglobals
+// pgErrorCode extracts the SQLSTATE code from an error returned by one of the PostgreSQL drivers.
+func pgErrorCode(e error) (string, bool) {
+	var pgErr *pgconn.PgError
+	if errors.As(e, &pgErr) {
+		return pgErr.Code, true
+	}
+
+	var pgErrV4 *pgconnv4.PgError
+	if errors.As(e, &pgErrV4) {
+		return pgErrV4.Code, true
+	}
 
-func errorTester(text string) AuthError {
-	return func(e error) bool {
-		return strings.Contains(strings.ToLower(e.Error()), text) || errors.Is(e, driver.ErrBadConn)
+	var pqErr *pq.Error
+	if errors.As(e, &pqErr) {
+		return string(pqErr.Code), true
 	}
+
+	return "", false
+}
+
+func matchesAny(e error, patterns []*regexp.Regexp) bool {
+	msg := strings.ToLower(e.Error())
+
+	for _, p := range patterns {
+		if p.MatchString(msg) {
+			return true
+		}
+	}
+
+	return false
 }
@@ -0,0 +1,191 @@
+package driver
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	pgconnv4 "github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+)
+
+// errorTester returns an AuthError that matches errors containing text, which lets tests fail
+// authentication with plain errors.
+func errorTester(text string) AuthError {
+	return func(e error) bool {
+		return strings.Contains(strings.ToLower(e.Error()), text)
+	}
+}
+
+func TestPostgreSQLAuthError(t *testing.T) {
+	testCases := []struct {
+		err         error
+		description string
+		expected    bool
+	}{
+		{
+			description: "pgx v5 - invalid password",
+			err:         &pgconn.PgError{Code: "28P01"},
+			expected:    true,
+		},
+		{
+			description: "pgx v5 - wrapped invalid authorization specification",
+			err:         fmt.Errorf("failed to connect: %w", &pgconn.PgError{Code: "28000"}),
+			expected:    true,
+		},
+		{
+			description: "pgx v5 - localized message with non-auth code",
+			err:         &pgconn.PgError{Code: "42P01", Message: PgErrorText},
+			expected:    false,
+		},
+		{
+			description: "pgx v4 - invalid password",
+			err:         &pgconnv4.PgError{Code: "28P01", Message: "la autentificación password falló"},
+			expected:    true,
+		},
+		{
+			description: "pgx v4 - too many connections",
+			err:         &pgconnv4.PgError{Code: "53300"},
+			expected:    false,
+		},
+		{
+			description: "pgx v5 - role revoked by vault",
+			err:         &pgconn.PgError{Code: "28000", Message: `role "v-token-xyz" does not exist`},
+			expected:    true,
+		},
+		{
+			description: "pgx v4 - role revoked by vault",
+			err:         &pgconnv4.PgError{Code: "28000", Message: `role "v-token-xyz" does not exist`},
+			expected:    true,
+		},
+		{
+			description: "pgx v4 - role cannot log in",
+			err:         &pgconnv4.PgError{Code: "28000", Message: `role "v-token-xyz" is not permitted to log in`},
+			expected:    true,
+		},
+		{
+			description: "pq - role revoked by vault",
+			err:         &pq.Error{Code: "28000", Message: `role "v-token-xyz" does not exist`},
+			expected:    true,
+		},
+		{
+			description: "pq - invalid password",
+			err:         &pq.Error{Code: "28P01"},
+			expected:    true,
+		},
+		{
+			description: "pq - database does not exist",
+			err:         &pq.Error{Code: "3D000"},
+			expected:    false,
+		},
+		{
+			description: "untyped error - falls back to error text",
+			err:         errors.New(`FATAL: Password authentication failed for user "foo"`),
+			expected:    true,
+		},
+		{
+			description: "untyped error - role revoked by vault",
+			err:         errors.New(`pq: role "v-token-xyz" does not exist`),
+			expected:    true,
+		},
+		{
+			description: "untyped error - role cannot log in",
+			err:         errors.New(`FATAL: role "v-token-xyz" is not permitted to log in (SQLSTATE 28000)`),
+			expected:    true,
+		},
+		{
+			description: "untyped error - database does not exist",
+			err:         errors.New(`FATAL: database "v-token-xyz" does not exist (SQLSTATE 3D000)`),
+			expected:    false,
+		},
+		{
+			description: "untyped error - unrelated text",
+			err:         errors.New("connection refused"),
+			expected:    false,
+		},
+		{
+			description: "bad connection",
+			err:         driver.ErrBadConn,
+			expected:    false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			if actual := PostgreSQLAuthError(testCase.err); actual != testCase.expected {
+				t.Fatalf("expected %t but got %t for '%v'", testCase.expected, actual, testCase.err)
+			}
+		})
+	}
+}
+
+func TestMySQLAuthError(t *testing.T) {
+	testCases := []struct {
+		err         error
+		description string
+		expected    bool
+	}{
+		{
+			description: "access denied",
+			err:         &mysql.MySQLError{Number: 1045, Message: "Access denied for user 'foo'@'localhost'"},
+			expected:    true,
+		},
+		{
+			description: "wrapped access denied without password",
+			err:         fmt.Errorf("failed to connect: %w", &mysql.MySQLError{Number: 1698}),
+			expected:    true,
+		},
+		{
+			description: "revoked user denied access to database",
+			err:         &mysql.MySQLError{Number: 1044, Message: "Access denied for user 'v-token-xyz'@'%' to database 'app'"},
+			expected:    true,
+		},
+		{
+			description: "expired password",
+			err:         &mysql.MySQLError{Number: 1862, Message: "Your password has expired."},
+			expected:    true,
+		},
+		{
+			description: "locked account",
+			err:         &mysql.MySQLError{Number: 3118, Message: "Access denied for user 'foo'@'localhost'. Account is locked."},
+			expected:    true,
+		},
+		{
+			description: "non-auth error number with matching text",
+			err:         &mysql.MySQLError{Number: 1040, Message: MysqlErrorText},
+			expected:    false,
+		},
+		{
+			description: "untyped error - falls back to error text",
+			err:         errors.New("Error 1045: Access denied for user 'foo'@'localhost'"),
+			expected:    true,
+		},
+		{
+			description: "untyped error - expired password",
+			err:         errors.New("Error 1862: Your password has expired. To log in you must change it"),
+			expected:    true,
+		},
+		{
+			description: "untyped error - unrelated text",
+			err:         errors.New("connection refused"),
+			expected:    false,
+		},
+		{
+			description: "bad connection",
+			err:         driver.ErrBadConn,
+			expected:    false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			if actual := MySQLAuthError(testCase.err); actual != testCase.expected {
+				t.Fatalf("expected %t but got %t for '%v'", testCase.expected, actual, testCase.err)
+			}
+		})
+	}
+}
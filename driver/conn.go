@@ -0,0 +1,190 @@
+package driver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+)
+
+var (
+	errIsolationLevelNotSupported  = errors.New("driver does not support non-default isolation level")
+	errReadOnlyNotSupported        = errors.New("driver does not support read-only transactions")
+	errNamedParametersNotSupported = errors.New("driver does not support the use of Named Parameters")
+)
+
+// conn wraps a connection opened by the Connector and records the generation of the credentials it
+// was authenticated with. Once the Connector's credentials have been replaced with a different
+// username or password, database/sql drops connections from older generations from its pool instead
+// of reusing them, so sessions don't outlive the credentials they were opened with.
+//
+// Every optional database/sql/driver interface is passed through to the underlying connection. When
+// the underlying connection doesn't implement one, conn falls back to the behavior database/sql would
+// have used in its place.
+type conn struct {
+	driver.Conn
+	connector  *Connector
+	generation uint64
+}
+
+var (
+	_ driver.Pinger             = (*conn)(nil)
+	_ driver.ExecerContext      = (*conn)(nil)
+	_ driver.QueryerContext     = (*conn)(nil)
+	_ driver.ConnPrepareContext = (*conn)(nil)
+	_ driver.ConnBeginTx        = (*conn)(nil)
+	_ driver.NamedValueChecker  = (*conn)(nil)
+	_ driver.SessionResetter    = (*conn)(nil)
+	_ driver.Validator          = (*conn)(nil)
+)
+
+// Unwrap returns the underlying driver connection. It is useful with database/sql.Conn.Raw when
+// driver-specific functionality is needed.
+func (c *conn) Unwrap() driver.Conn {
+	return c.Conn
+}
+
+// stale reports whether the credentials this connection was opened with have since been replaced.
+func (c *conn) stale() bool {
+	return c.generation < c.connector.current().generation
+}
+
+// IsValid implements the driver.Validator interface.
+func (c *conn) IsValid() bool {
+	if c.stale() {
+		return false
+	}
+
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+
+	return true
+}
+
+// ResetSession implements the driver.SessionResetter interface.
+func (c *conn) ResetSession(ctx context.Context) error {
+	if c.stale() {
+		return driver.ErrBadConn
+	}
+
+	if sr, ok := c.Conn.(driver.SessionResetter); ok {
+		return sr.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+// Ping implements the driver.Pinger interface.
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+
+	return nil
+}
+
+// ExecContext implements the driver.ExecerContext interface.
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if e, ok := c.Conn.(driver.ExecerContext); ok {
+		return e.ExecContext(ctx, query, args)
+	}
+
+	if e, ok := c.Conn.(driver.Execer); ok { //nolint:staticcheck
+		values, err := namedValuesToValues(args)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		return e.Exec(query, values)
+	}
+
+	return nil, driver.ErrSkip
+}
+
+// QueryContext implements the driver.QueryerContext interface.
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if q, ok := c.Conn.(driver.QueryerContext); ok {
+		return q.QueryContext(ctx, query, args)
+	}
+
+	if q, ok := c.Conn.(driver.Queryer); ok { //nolint:staticcheck
+		values, err := namedValuesToValues(args)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		return q.Query(query, values)
+	}
+
+	return nil, driver.ErrSkip
+}
+
+// PrepareContext implements the driver.ConnPrepareContext interface.
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return c.Prepare(query)
+}
+
+// BeginTx implements the driver.ConnBeginTx interface.
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+
+	// These are the same checks database/sql makes for drivers without ConnBeginTx.
+	if sql.IsolationLevel(opts.Isolation) != sql.LevelDefault {
+		return nil, errIsolationLevelNotSupported
+	}
+
+	if opts.ReadOnly {
+		return nil, errReadOnlyNotSupported
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return c.Begin() //nolint:staticcheck
+}
+
+// CheckNamedValue implements the driver.NamedValueChecker interface.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+
+	// ErrSkip tells database/sql to use its default conversion instead.
+	return driver.ErrSkip
+}
+
+// namedValuesToValues converts arguments for drivers that only implement the legacy Execer and
+// Queryer interfaces, which don't support named parameters.
+func namedValuesToValues(named []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(named))
+
+	for i, nv := range named {
+		if nv.Name != "" {
+			return nil, errNamedParametersNotSupported
+		}
+
+		values[i] = nv.Value
+	}
+
+	return values, nil
+}
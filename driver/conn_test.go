@@ -0,0 +1,500 @@
+package driver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testConn is a bare driver.Conn that doesn't implement any optional interfaces.
+type testConn struct {
+	mu     sync.Mutex
+	calls  []string
+	closed bool
+}
+
+func (c *testConn) record(call string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls = append(c.calls, call)
+}
+
+func (c *testConn) called(call string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, c := range c.calls {
+		if c == call {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *testConn) Prepare(_ string) (driver.Stmt, error) {
+	c.record("Prepare")
+
+	return &testStmt{}, nil
+}
+
+func (c *testConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+
+	return nil
+}
+
+func (c *testConn) Begin() (driver.Tx, error) {
+	c.record("Begin")
+
+	return &testTx{}, nil
+}
+
+// testLegacyConn only implements the legacy Execer and Queryer interfaces.
+type testLegacyConn struct {
+	testConn
+}
+
+func (c *testLegacyConn) Exec(_ string, _ []driver.Value) (driver.Result, error) { //nolint:staticcheck
+	c.record("Exec")
+
+	return driver.RowsAffected(1), nil
+}
+
+func (c *testLegacyConn) Query(_ string, _ []driver.Value) (driver.Rows, error) { //nolint:staticcheck
+	c.record("Query")
+
+	return &testRows{}, nil
+}
+
+// testFullConn implements every optional interface the conn wrapper passes through.
+type testFullConn struct {
+	testConn
+	valid bool
+}
+
+func (c *testFullConn) Ping(_ context.Context) error {
+	c.record("Ping")
+
+	return nil
+}
+
+func (c *testFullConn) ExecContext(_ context.Context, _ string, _ []driver.NamedValue) (driver.Result, error) {
+	c.record("ExecContext")
+
+	return driver.RowsAffected(1), nil
+}
+
+func (c *testFullConn) QueryContext(_ context.Context, _ string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.record("QueryContext")
+
+	return &testRows{}, nil
+}
+
+func (c *testFullConn) PrepareContext(_ context.Context, _ string) (driver.Stmt, error) {
+	c.record("PrepareContext")
+
+	return &testStmt{}, nil
+}
+
+func (c *testFullConn) BeginTx(_ context.Context, _ driver.TxOptions) (driver.Tx, error) {
+	c.record("BeginTx")
+
+	return &testTx{}, nil
+}
+
+func (c *testFullConn) CheckNamedValue(_ *driver.NamedValue) error {
+	c.record("CheckNamedValue")
+
+	return nil
+}
+
+func (c *testFullConn) ResetSession(_ context.Context) error {
+	c.record("ResetSession")
+
+	return nil
+}
+
+func (c *testFullConn) IsValid() bool {
+	c.record("IsValid")
+
+	return c.valid
+}
+
+type testStmt struct{}
+
+func (*testStmt) Close() error  { return nil }
+func (*testStmt) NumInput() int { return -1 }
+
+func (*testStmt) Exec(_ []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+func (*testStmt) Query(_ []driver.Value) (driver.Rows, error) {
+	return &testRows{}, nil
+}
+
+type testTx struct{}
+
+func (*testTx) Commit() error   { return nil }
+func (*testTx) Rollback() error { return nil }
+
+type testRows struct{}
+
+func (*testRows) Columns() []string           { return nil }
+func (*testRows) Close() error                { return nil }
+func (*testRows) Next(_ []driver.Value) error { return io.EOF }
+
+// testConnDriver hands out the connections returned by newConn.
+type testConnDriver struct {
+	mu      sync.Mutex
+	newConn func() driver.Conn
+	conns   []driver.Conn
+}
+
+func (d *testConnDriver) Open(_ string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	c := d.newConn()
+	d.conns = append(d.conns, c)
+
+	return c, nil
+}
+
+func newTestConnConnector(t *testing.T, d driver.Driver) *Connector {
+	t.Helper()
+
+	return newTestConnConnectorWithRefresh(t, d, rotatingCredentials())
+}
+
+func newTestConnConnectorWithRefresh(
+	t *testing.T,
+	d driver.Driver,
+	refresh func(context.Context) (Credentials, error),
+) *Connector {
+	t.Helper()
+
+	unregisterAllDrivers()
+	if err := Register("driver", func() *Driver {
+		return &Driver{
+			Driver:    d,
+			Formatter: MysqlFormatter,
+			AuthError: errorTester(MysqlErrorText),
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	getFn := func(ctx context.Context) (Credentials, error) {
+		return &testCredential{
+			Username: username,
+			Password: password,
+		}, nil
+	}
+
+	c, err := NewConnector(&testStore{
+		Getter:    getFn,
+		Refresher: refresh,
+	}, "driver", &Config{
+		Host: host,
+		Port: port,
+		DB:   "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func TestConnPassesThroughOptionalInterfaces(t *testing.T) {
+	inner := &testFullConn{valid: true}
+	c := newTestConnConnector(t, &testConnDriver{newConn: func() driver.Conn { return inner }})
+
+	dc, err := c.Connect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wrapped, ok := dc.(*conn)
+	if !ok {
+		t.Fatalf("expected a *conn but got a %T instead", dc)
+	}
+
+	if wrapped.Unwrap() != inner {
+		t.Fatal("expected Unwrap to return the underlying connection")
+	}
+
+	ctx := context.Background()
+
+	if err := wrapped.Ping(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := wrapped.ExecContext(ctx, "", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := wrapped.QueryContext(ctx, "", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := wrapped.PrepareContext(ctx, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := wrapped.BeginTx(ctx, driver.TxOptions{ReadOnly: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wrapped.CheckNamedValue(&driver.NamedValue{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wrapped.ResetSession(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if !wrapped.IsValid() {
+		t.Fatal("expected connection to be valid")
+	}
+
+	for _, call := range []string{
+		"Ping",
+		"ExecContext",
+		"QueryContext",
+		"PrepareContext",
+		"BeginTx",
+		"CheckNamedValue",
+		"ResetSession",
+		"IsValid",
+	} {
+		if !inner.called(call) {
+			t.Fatalf("expected %s to be passed through to the underlying connection", call)
+		}
+	}
+
+	inner.valid = false
+	if wrapped.IsValid() {
+		t.Fatal("expected connection to be invalid when the underlying connection is")
+	}
+}
+
+func TestConnFallsBackWhenOptionalInterfacesAreMissing(t *testing.T) {
+	inner := &testConn{}
+	wrapped := &conn{Conn: inner, connector: &Connector{}}
+	ctx := context.Background()
+
+	if err := wrapped.Ping(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := wrapped.ExecContext(ctx, "", nil); !errors.Is(err, driver.ErrSkip) {
+		t.Fatalf("expected '%v' but got '%v' instead", driver.ErrSkip, err)
+	}
+
+	if _, err := wrapped.QueryContext(ctx, "", nil); !errors.Is(err, driver.ErrSkip) {
+		t.Fatalf("expected '%v' but got '%v' instead", driver.ErrSkip, err)
+	}
+
+	if err := wrapped.CheckNamedValue(&driver.NamedValue{}); !errors.Is(err, driver.ErrSkip) {
+		t.Fatalf("expected '%v' but got '%v' instead", driver.ErrSkip, err)
+	}
+
+	if _, err := wrapped.PrepareContext(ctx, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if !inner.called("Prepare") {
+		t.Fatal("expected PrepareContext to fall back to Prepare")
+	}
+
+	if _, err := wrapped.BeginTx(ctx, driver.TxOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !inner.called("Begin") {
+		t.Fatal("expected BeginTx to fall back to Begin")
+	}
+
+	if _, err := wrapped.BeginTx(ctx, driver.TxOptions{ReadOnly: true}); !errors.Is(err, errReadOnlyNotSupported) {
+		t.Fatalf("expected '%v' but got '%v' instead", errReadOnlyNotSupported, err)
+	}
+
+	isolation := driver.TxOptions{Isolation: driver.IsolationLevel(sql.LevelSerializable)}
+	if _, err := wrapped.BeginTx(ctx, isolation); !errors.Is(err, errIsolationLevelNotSupported) {
+		t.Fatalf("expected '%v' but got '%v' instead", errIsolationLevelNotSupported, err)
+	}
+
+	if err := wrapped.ResetSession(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if !wrapped.IsValid() {
+		t.Fatal("expected connection to be valid")
+	}
+}
+
+func TestConnFallsBackToLegacyExecerAndQueryer(t *testing.T) {
+	inner := &testLegacyConn{}
+	wrapped := &conn{Conn: inner, connector: &Connector{}}
+	ctx := context.Background()
+
+	if _, err := wrapped.ExecContext(ctx, "", []driver.NamedValue{{Ordinal: 1, Value: 1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := wrapped.QueryContext(ctx, "", []driver.NamedValue{{Ordinal: 1, Value: 1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !inner.called("Exec") || !inner.called("Query") {
+		t.Fatal("expected ExecContext and QueryContext to fall back to Exec and Query")
+	}
+
+	named := []driver.NamedValue{{Name: "foo", Ordinal: 1, Value: 1}}
+	if _, err := wrapped.ExecContext(ctx, "", named); !errors.Is(err, errNamedParametersNotSupported) {
+		t.Fatalf("expected '%v' but got '%v' instead", errNamedParametersNotSupported, err)
+	}
+}
+
+func TestConnIsRetiredAfterCredentialsAreReplaced(t *testing.T) {
+	c := newTestConnConnector(t, &testConnDriver{newConn: func() driver.Conn { return &testFullConn{valid: true} }})
+	ctx := context.Background()
+
+	dc, err := c.Connect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wrapped, ok := dc.(*conn)
+	if !ok {
+		t.Fatalf("expected a *conn but got a %T instead", dc)
+	}
+
+	if !wrapped.IsValid() {
+		t.Fatal("expected connection to be valid before credentials are replaced")
+	}
+
+	if _, err := c.refresh(ctx, c.current().version); err != nil {
+		t.Fatal(err)
+	}
+
+	if wrapped.IsValid() {
+		t.Fatal("expected connection to be invalid after credentials are replaced")
+	}
+
+	if err := wrapped.ResetSession(ctx); !errors.Is(err, driver.ErrBadConn) {
+		t.Fatalf("expected '%v' but got '%v' instead", driver.ErrBadConn, err)
+	}
+}
+
+func TestConnIsOnlyRetiredWhenCredentialsChange(t *testing.T) {
+	testCases := []struct {
+		description string
+		refreshed   *testCredential
+		retired     bool
+	}{
+		{
+			description: "same credentials",
+			refreshed:   &testCredential{Username: username, Password: password},
+		},
+		{
+			description: "new token for the same user",
+			refreshed:   &testCredential{Username: username, Password: "token", Token: true},
+		},
+		{
+			description: "new password",
+			refreshed:   &testCredential{Username: username, Password: "new"},
+			retired:     true,
+		},
+		{
+			description: "new user",
+			refreshed:   &testCredential{Username: "new", Password: "token", Token: true},
+			retired:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			tc.refreshed.Expiration = time.Now().Add(time.Hour)
+
+			c := newTestConnConnectorWithRefresh(t,
+				&testConnDriver{newConn: func() driver.Conn { return &testFullConn{valid: true} }},
+				func(_ context.Context) (Credentials, error) { return tc.refreshed, nil })
+			defer c.Close()
+
+			ctx := context.Background()
+
+			dc, err := c.Connect(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			wrapped, ok := dc.(*conn)
+			if !ok {
+				t.Fatalf("expected a *conn but got a %T instead", dc)
+			}
+
+			if _, err := c.refresh(ctx, c.current().version); err != nil {
+				t.Fatal(err)
+			}
+
+			if wrapped.IsValid() == tc.retired {
+				t.Fatalf("expected IsValid to be '%v' but got '%v' instead", !tc.retired, wrapped.IsValid())
+			}
+		})
+	}
+}
+
+func TestDBDropsConnectionsOpenedWithReplacedCredentials(t *testing.T) {
+	d := &testConnDriver{newConn: func() driver.Conn { return &testFullConn{valid: true} }}
+	c := newTestConnConnector(t, d)
+	ctx := context.Background()
+
+	db := sql.OpenDB(c)
+	defer db.Close()
+
+	db.SetMaxIdleConns(1)
+
+	if err := db.PingContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.refresh(ctx, c.current().version); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.conns) != 2 {
+		t.Fatalf("expected 2 connections to have been opened but got %d", len(d.conns))
+	}
+
+	first, ok := d.conns[0].(*testFullConn)
+	if !ok {
+		t.Fatalf("expected a *testFullConn but got a %T instead", d.conns[0])
+	}
+
+	first.mu.Lock()
+	defer first.mu.Unlock()
+
+	if !first.closed {
+		t.Fatal("expected the connection opened with the replaced credentials to be closed")
+	}
+}
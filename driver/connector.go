@@ -4,7 +4,10 @@ import (
 	"context"
 	"database/sql/driver"
 	"errors"
+	"io"
+	"log/slog"
 	"sync"
+	"time"
 )
 
 // Config is a struct that holds non-credential database configuration.
@@ -14,14 +17,45 @@ type Config struct {
 	Host      string
 	DB        string
 	Port      int
-	Retries   int
+	// Retries is how many times to retry a connection that fails to authenticate. It is ignored
+	// when RetryPolicy is set.
+	Retries int
+	// RetryPolicy decides whether and when to retry a connection that fails to authenticate. It
+	// defaults to a ConstantBackoff that retries Retries times without waiting.
+	RetryPolicy RetryPolicy
+	// OnRetry is called before each retry, which makes it possible to log or record the delay
+	// chosen by the RetryPolicy for each attempt.
+	OnRetry RetryHook
+	// RefreshBefore is how long before ExpiringCredentials expire that the Connector refreshes them
+	// in the background. It defaults to DefaultRefreshBefore and is capped at half of the remaining
+	// lifetime of the credentials so short-lived credentials aren't refreshed continuously.
+	RefreshBefore time.Duration
+	// Observer is notified when credentials are fetched, refreshed and rejected by the database, and
+	// when connections are opened. It defaults to NopObserver.
+	Observer Observer
+	// Tracer traces each call to Connect and the attempts it makes to open a connection. It is
+	// optional.
+	Tracer Tracer
+	// Logger logs when credentials are fetched, refreshed and rejected by the database. Passwords
+	// are never logged. It is optional.
+	Logger *slog.Logger
 }
 
+const (
+	// DefaultRefreshBefore is the default value of Config.RefreshBefore.
+	DefaultRefreshBefore = time.Minute
+
+	backgroundRefreshTimeout = 30 * time.Second
+	backgroundRetryDelay     = 5 * time.Second
+	closeTimeout             = 30 * time.Second
+)
+
 var (
 	ErrConfigRequired   = errors.New("config is required")
 	ErrNoNilCredentials = errors.New("store cannot return nil credentials")
 	ErrMissingUsername  = errors.New("missing username")
 	ErrMissingPassword  = errors.New("missing password")
+	ErrConnectorClosed  = errors.New("connector is closed")
 )
 
 // NewConnector creates a new connector from a store.
@@ -46,54 +80,139 @@ func NewConnector(s Store, driverName string, cfg *Config) (*Connector, error) {
 		cfg.Retries = 1
 	}
 
-	return &Connector{
-		store:      s,
-		cfg:        cfg,
-		driver:     d.Driver,
-		errHandler: d.AuthError,
-		formatter:  d.Formatter,
-		mu:         sync.Mutex{},
-	}, nil
+	if cfg.RefreshBefore <= 0 {
+		cfg.RefreshBefore = DefaultRefreshBefore
+	}
+
+	retryPolicy := cfg.RetryPolicy
+	if retryPolicy == nil {
+		retryPolicy = ConstantBackoff{Retries: cfg.Retries}
+	}
+
+	tracer := cfg.Tracer
+	if tracer == nil {
+		tracer = nopTracer{}
+	}
+
+	c := &Connector{
+		store:       s,
+		cfg:         cfg,
+		driver:      d.Driver,
+		errHandler:  d.AuthError,
+		formatter:   d.Formatter,
+		retryPolicy: retryPolicy,
+		observer:    newObserver(cfg),
+		tracer:      tracer,
+		logger:      cfg.Logger,
+		retryDelay:  backgroundRetryDelay,
+		info: ConnectInfo{
+			Driver: driverName,
+			Host:   cfg.Host,
+			Port:   cfg.Port,
+			DB:     cfg.DB,
+		},
+		mu: sync.Mutex{},
+	}
+
+	if n, ok := s.(Notifier); ok {
+		c.stopNotify = n.Notify(c.notified)
+	}
+
+	return c, nil
+}
+
+// newObserver combines the Observers configured in cfg.
+func newObserver(cfg *Config) Observer {
+	var observers MultiObserver
+
+	for _, o := range []Observer{cfg.Observer, newLogObserver(cfg.Logger)} {
+		if o != nil {
+			observers = append(observers, o)
+		}
+	}
+
+	switch len(observers) {
+	case 0:
+		return NopObserver{}
+	case 1:
+		return observers[0]
+	default:
+		return observers
+	}
 }
 
 // Connector represents a driver in a fixed configuration.
+//
+// The Connector caches the credentials it gets from its Store and shares them between connection
+// attempts. Calls to the Store are deduplicated so that any number of concurrent connection attempts
+// failing with the same credentials only trigger a single Refresh, while the connection attempts
+// themselves run concurrently.
 type Connector struct {
-	store      Store
-	cfg        *Config
-	driver     driver.Driver
-	errHandler AuthError
-	formatter  Formatter
-	mu         sync.Mutex
+	store       Store
+	cfg         *Config
+	driver      driver.Driver
+	errHandler  AuthError
+	formatter   Formatter
+	retryPolicy RetryPolicy
+	observer    Observer
+	tracer      Tracer
+	logger      *slog.Logger
+	info        ConnectInfo
+	// retryDelay is how long a background refresh that made no progress waits before trying again.
+	retryDelay time.Duration
+	// stopNotify unsubscribes from a Store that implements Notifier.
+	stopNotify func()
+	// mu guards the fields below. It is never held while calling the Store or the database.
+	mu sync.Mutex
+	// creds are the current credentials. version counts how many times the Store has returned
+	// credentials and generation how many times they've been replaced with a different username or
+	// password, except for a new login token for the same user.
+	creds      Credentials
+	version    uint64
+	generation uint64
+	// flight is the in-progress call to the Store, if any.
+	flight *flight
+	// inner is the underlying driver's connector for the credentials of innerVersion.
+	inner        driver.Connector
+	innerVersion uint64
+	// timer fires the background refresh for the credentials expiring at scheduledExpiry.
+	timer           *time.Timer
+	scheduledExpiry time.Time
+	closed          bool
 }
 
+var _ io.Closer = (*Connector)(nil)
+
 // Connect implements driver.Connector interface.
 func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	creds, err := c.store.Get(ctx)
-	if err != nil {
-		return nil, err
-	}
+	ctx, end := c.tracer.StartConnect(ctx, c.info)
 
-	if creds == nil {
-		return nil, ErrNoNilCredentials
-	}
+	conn, err := c.connect(ctx)
+	end(err)
 
-	username := creds.GetUsername()
-	password := creds.GetPassword()
+	return conn, err
+}
 
-	if username == "" {
-		return nil, ErrMissingUsername
+func (c *Connector) connect(ctx context.Context) (driver.Conn, error) {
+	if c.isClosed() {
+		return nil, ErrConnectorClosed
 	}
 
-	if password == "" {
-		return nil, ErrMissingPassword
+	s, err := c.credentials(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	connStr := c.formatter(username, password, c.cfg.Host, c.cfg.Port, c.cfg.DB, c.cfg.Opts)
+	// Credentials we know have already expired would only be rejected by the database so we
+	// refresh them up front instead of spending a connection attempt on them.
+	if exp := ExpiresAt(s.creds); !exp.IsZero() && !time.Now().Before(exp) {
+		s, err = c.refresh(ctx, s.version)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	conn, err := c.driver.Open(connStr)
+	conn, err := c.open(ctx, s, 0)
 	if err == nil {
 		return conn, nil
 	}
@@ -102,22 +221,35 @@ func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
 		return nil, err
 	}
 
-	for i := 0; i < c.cfg.Retries; i++ {
-		creds, err = c.store.Refresh(ctx)
-		if err != nil {
+	c.observer.OnAuthFailure(ctx, err, 0)
+
+	var (
+		delay   time.Duration
+		retries int
+	)
+
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		if delay, retry = c.retryPolicy.Backoff(attempt, delay); !retry {
+			break
+		}
+
+		retries = attempt
+
+		if c.cfg.OnRetry != nil {
+			c.cfg.OnRetry(attempt, delay, err)
+		}
+
+		if err := sleep(ctx, delay); err != nil {
 			return nil, err
 		}
 
-		connStr = c.formatter(
-			creds.GetUsername(),
-			creds.GetPassword(),
-			c.cfg.Host,
-			c.cfg.Port,
-			c.cfg.DB,
-			c.cfg.Opts,
-		)
+		s, err = c.refresh(ctx, s.version)
+		if err != nil {
+			return nil, err
+		}
 
-		conn, err = c.driver.Open(connStr)
+		conn, err = c.open(ctx, s, attempt)
 		if err == nil {
 			return conn, nil
 		}
@@ -126,8 +258,12 @@ func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
 		if !c.errHandler(err) {
 			return nil, err
 		}
+
+		c.observer.OnAuthFailure(ctx, err, attempt)
 	}
 
+	c.observer.OnRetriesExhausted(ctx, err, retries)
+
 	// If we've exhausted our retries we'll just return the last error
 	return nil, err
 }
@@ -136,3 +272,176 @@ func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
 func (c *Connector) Driver() driver.Driver {
 	return c.driver
 }
+
+// Close implements io.Closer. database/sql calls it from DB.Close. It stops refreshing credentials in
+// the background and waits for any in-progress call to the Store before revoking the Store's
+// credentials if it implements Revoker and closing it if it implements io.Closer.
+func (c *Connector) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+
+		return nil
+	}
+
+	c.closed = true
+
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+
+	f, inner := c.flight, c.inner
+	c.mu.Unlock()
+
+	if c.stopNotify != nil {
+		c.stopNotify()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+	defer cancel()
+
+	// Credentials being fetched right now would otherwise be issued after we've revoked the
+	// current ones and never be cleaned up.
+	if f != nil {
+		_, _ = f.wait(ctx)
+	}
+
+	var errs []error
+
+	if r, ok := c.store.(Revoker); ok {
+		errs = append(errs, r.Revoke(ctx))
+	}
+
+	if closer, ok := c.store.(io.Closer); ok {
+		errs = append(errs, closer.Close())
+	}
+
+	if closer, ok := inner.(io.Closer); ok {
+		errs = append(errs, closer.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *Connector) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.closed
+}
+
+// current returns the current credentials.
+func (c *Connector) current() snapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.snapshot()
+}
+
+// open connects to the database with the credentials in s. The connection is wrapped so
+// database/sql stops reusing it once those credentials have been replaced.
+func (c *Connector) open(ctx context.Context, s snapshot, attempt int) (driver.Conn, error) {
+	ctx, end := c.tracer.StartOpen(ctx, attempt)
+
+	connector, err := c.connector(s)
+	if err != nil {
+		end(err, false)
+
+		return nil, err
+	}
+
+	inner, err := connector.Connect(ctx)
+	if err != nil {
+		end(err, c.errHandler(err))
+
+		return nil, err
+	}
+
+	end(nil, false)
+
+	c.observer.OnConnect(ctx, s.generation)
+
+	return &conn{Conn: inner, connector: c, generation: s.generation}, nil
+}
+
+// connector returns a connector from the underlying driver for the credentials in s. Drivers
+// implementing driver.DriverContext only have to parse the DSN once per version of the credentials
+// and can honor the context passed to Connect while dialing.
+func (c *Connector) connector(s snapshot) (driver.Connector, error) {
+	creds := s.creds
+
+	c.mu.Lock()
+	if c.inner != nil && c.innerVersion == s.version {
+		inner := c.inner
+		c.mu.Unlock()
+
+		return inner, nil
+	}
+	c.mu.Unlock()
+
+	connStr := c.formatter(
+		creds.GetUsername(),
+		creds.GetPassword(),
+		c.cfg.Host,
+		c.cfg.Port,
+		c.cfg.DB,
+		c.cfg.Opts,
+	)
+
+	if c.logger != nil {
+		c.logger.Debug("creating driver connector", "dsn", RedactDSN(connStr), "version", s.version)
+	}
+
+	var inner driver.Connector = dsnConnector{dsn: connStr, driver: c.driver}
+
+	if dc, ok := c.driver.(driver.DriverContext); ok {
+		var err error
+		if inner, err = dc.OpenConnector(connStr); err != nil {
+			return nil, err
+		}
+	}
+
+	c.mu.Lock()
+	if s.version >= c.innerVersion {
+		c.inner, c.innerVersion = inner, s.version
+	}
+	c.mu.Unlock()
+
+	return inner, nil
+}
+
+// dsnConnector adapts a driver that doesn't implement driver.DriverContext to the driver.Connector
+// interface the same way database/sql does.
+type dsnConnector struct {
+	driver driver.Driver
+	dsn    string
+}
+
+// Connect implements driver.Connector interface.
+func (t dsnConnector) Connect(_ context.Context) (driver.Conn, error) {
+	return t.driver.Open(t.dsn)
+}
+
+// Driver implements driver.Connector interface.
+func (t dsnConnector) Driver() driver.Driver {
+	return t.driver
+}
+
+// ValidateCredentials returns ErrNoNilCredentials, ErrMissingUsername or ErrMissingPassword when creds
+// can't be used to connect. The Connector rejects credentials from its Store that fail it.
+func ValidateCredentials(creds Credentials) error {
+	if creds == nil {
+		return ErrNoNilCredentials
+	}
+
+	if creds.GetUsername() == "" {
+		return ErrMissingUsername
+	}
+
+	if creds.GetPassword() == "" {
+		return ErrMissingPassword
+	}
+
+	return nil
+}
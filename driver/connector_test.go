@@ -2,9 +2,16 @@ package driver
 
 import (
 	"context"
+	"database/sql"
 	"database/sql/driver"
 	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 const (
@@ -27,13 +34,33 @@ func (vs *testStore) Refresh(ctx context.Context) (Credentials, error) {
 	return vs.Refresher(ctx)
 }
 
+// testRevokingStore is a testStore that also implements Revoker and io.Closer.
+type testRevokingStore struct {
+	testStore
+	Revoked int
+	Closed  int
+}
+
+func (rs *testRevokingStore) Revoke(_ context.Context) error {
+	rs.Revoked++
+
+	return nil
+}
+
+func (rs *testRevokingStore) Close() error {
+	rs.Closed++
+
+	return nil
+}
+
 type testDriver struct {
-	Called         int
-	ConnStr        string
-	Conn           driver.Conn
-	ConnErr        error
-	Connector      driver.Connector
-	ConnectorError error
+	Called              int
+	OpenConnectorCalled int
+	ConnStr             string
+	Conn                driver.Conn
+	ConnErr             error
+	Connector           driver.Connector
+	ConnectorError      error
 }
 
 func (d *testDriver) Open(dsn string) (driver.Conn, error) {
@@ -44,9 +71,32 @@ func (d *testDriver) Open(dsn string) (driver.Conn, error) {
 }
 
 func (d *testDriver) OpenConnector(dsn string) (driver.Connector, error) {
+	d.OpenConnectorCalled++
+
+	if d.Connector == nil {
+		return &testDriverConnector{driver: d, dsn: dsn}, d.ConnectorError
+	}
+
 	return d.Connector, d.ConnectorError
 }
 
+// testDriverConnector connects through its testDriver and records the context it was called with.
+type testDriverConnector struct {
+	driver *testDriver
+	dsn    string
+	ctx    context.Context
+}
+
+func (dc *testDriverConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	dc.ctx = ctx
+
+	return dc.driver.Open(dc.dsn)
+}
+
+func (dc *testDriverConnector) Driver() driver.Driver {
+	return dc.driver
+}
+
 type testFailingDriver struct {
 	Called  int
 	ConnErr error
@@ -80,9 +130,42 @@ func (rfd *testRetryingFailureDriver) Open(dsn string) (driver.Conn, error) {
 	return nil, nil
 }
 
+// testConcurrentDriver only accepts connections using goodPassword. Every call to Open blocks until
+// opens calls are in progress at the same time or a timeout elapses.
+type testConcurrentDriver struct {
+	goodPassword string
+	opens        int
+	mu           sync.Mutex
+	opening      int
+	ready        chan struct{}
+}
+
+func (cd *testConcurrentDriver) Open(dsn string) (driver.Conn, error) {
+	cd.mu.Lock()
+	cd.opening++
+	if cd.opening == cd.opens {
+		close(cd.ready)
+	}
+	cd.mu.Unlock()
+
+	select {
+	case <-cd.ready:
+	case <-time.After(2 * time.Second):
+		return nil, errors.New("timed out waiting for concurrent opens")
+	}
+
+	if !strings.Contains(dsn, ":"+cd.goodPassword+"@") {
+		return nil, errors.New(MysqlErrorText)
+	}
+
+	return nil, nil
+}
+
 type testCredential struct {
-	Username string
-	Password string
+	Username   string
+	Password   string
+	Expiration time.Time
+	Token      bool
 }
 
 func (c *testCredential) GetUsername() string {
@@ -93,6 +176,27 @@ func (c *testCredential) GetPassword() string {
 	return c.Password
 }
 
+func (c *testCredential) ExpiresAt() time.Time {
+	return c.Expiration
+}
+
+func (c *testCredential) IsToken() bool {
+	return c.Token
+}
+
+// rotatingCredentials returns a store func that issues a new password every time it's called, like
+// a store handing out dynamic credentials.
+func rotatingCredentials() func(context.Context) (Credentials, error) {
+	var n atomic.Int32
+
+	return func(_ context.Context) (Credentials, error) {
+		return &testCredential{
+			Username: username,
+			Password: password + strconv.Itoa(int(n.Add(1))),
+		}, nil
+	}
+}
+
 func TestNewConnectorFailsWithNilConfig(t *testing.T) {
 	unregisterAllDrivers()
 	if err := Register("driver", func() *Driver {
@@ -661,3 +765,821 @@ func TestConnectorErrorsIfUnknownDBErrorMessage(t *testing.T) {
 		t.Fatalf("expected driver.Open to only have been called once but it was called %d times", d.Called)
 	}
 }
+
+func TestConnectorRefreshesExpiringCredentialsInBackground(t *testing.T) {
+	unregisterAllDrivers()
+	d := &testDriver{}
+	if err := Register("driver", func() *Driver {
+		return &Driver{
+			Driver:    d,
+			Formatter: MysqlFormatter,
+			AuthError: errorTester(MysqlErrorText),
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	refreshed := make(chan struct{}, 1)
+
+	c, err := NewConnector(&testStore{
+		Getter: func(ctx context.Context) (Credentials, error) {
+			return &testCredential{
+				Username:   username,
+				Password:   password,
+				Expiration: time.Now().Add(200 * time.Millisecond),
+			}, nil
+		},
+		Refresher: func(ctx context.Context) (Credentials, error) {
+			select {
+			case refreshed <- struct{}{}:
+			default:
+			}
+
+			return &testCredential{
+				Username:   username,
+				Password:   password,
+				Expiration: time.Now().Add(time.Hour),
+			}, nil
+		},
+	}, "driver", &Config{
+		Host:          host,
+		Port:          port,
+		DB:            "test",
+		RefreshBefore: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-refreshed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected credentials to be refreshed in the background before they expired")
+	}
+
+	if d.Called != 1 {
+		t.Fatalf("expected driver.Open to only have been called once but it was called %d times", d.Called)
+	}
+}
+
+func TestConnectorRetriesBackgroundRefreshThatMakesNoProgress(t *testing.T) {
+	unregisterAllDrivers()
+	if err := Register("driver", func() *Driver {
+		return &Driver{
+			Driver:    &testConnDriver{newConn: func() driver.Conn { return &testFullConn{valid: true} }},
+			Formatter: MysqlFormatter,
+			AuthError: errorTester(MysqlErrorText),
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The store keeps returning the credentials it already issued, like a LastKnownGood store does
+	// while the store it wraps is unavailable.
+	creds := &testCredential{
+		Username:   username,
+		Password:   password,
+		Expiration: time.Now().Add(time.Second),
+	}
+
+	var refreshCalled atomic.Int32
+
+	c, err := NewConnector(&testStore{
+		Getter: func(ctx context.Context) (Credentials, error) {
+			return creds, nil
+		},
+		Refresher: func(ctx context.Context) (Credentials, error) {
+			refreshCalled.Add(1)
+
+			return creds, nil
+		},
+	}, "driver", &Config{
+		Host:          host,
+		Port:          port,
+		DB:            "test",
+		RefreshBefore: 800 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	c.retryDelay = 50 * time.Millisecond
+
+	dc, err := c.Connect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(900 * time.Millisecond)
+
+	if called := refreshCalled.Load(); called < 2 {
+		t.Fatalf("expected the background refresh to be retried but Refresh was called %d times", called)
+	}
+
+	if !dc.(*conn).IsValid() {
+		t.Fatal("expected the connection to stay valid when the store returns the same credentials")
+	}
+}
+
+func TestConnectorRefreshesExpiredCredentialsBeforeConnecting(t *testing.T) {
+	unregisterAllDrivers()
+	d := &testDriver{}
+	if err := Register("driver", func() *Driver {
+		return &Driver{
+			Driver:    d,
+			Formatter: MysqlFormatter,
+			AuthError: errorTester(MysqlErrorText),
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	newPassword := "baz"
+	refreshCalled := 0
+
+	c, err := NewConnector(&testStore{
+		Getter: func(ctx context.Context) (Credentials, error) {
+			return &testCredential{
+				Username:   username,
+				Password:   password,
+				Expiration: time.Now().Add(-time.Second),
+			}, nil
+		},
+		Refresher: func(ctx context.Context) (Credentials, error) {
+			refreshCalled++
+
+			return &testCredential{
+				Username:   username,
+				Password:   newPassword,
+				Expiration: time.Now().Add(time.Hour),
+			}, nil
+		},
+	}, "driver", &Config{
+		Host: host,
+		Port: port,
+		DB:   "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if refreshCalled != 1 {
+		t.Fatalf("expected Refresh func to have been called once but it was called %d times", refreshCalled)
+	}
+
+	dsn := MysqlFormatter(username, newPassword, host, port, "test", nil)
+	if d.ConnStr != dsn {
+		t.Fatalf("expected %s but got %s instead", dsn, d.ConnStr)
+	}
+}
+
+func TestConnectorOpensConnectionsConcurrently(t *testing.T) {
+	unregisterAllDrivers()
+	concurrency := 10
+	d := &testConcurrentDriver{
+		goodPassword: password,
+		opens:        concurrency,
+		ready:        make(chan struct{}),
+	}
+	if err := Register("driver", func() *Driver {
+		return &Driver{
+			Driver:    d,
+			Formatter: MysqlFormatter,
+			AuthError: errorTester(MysqlErrorText),
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var getCalled atomic.Int32
+
+	getFn := func(ctx context.Context) (Credentials, error) {
+		getCalled.Add(1)
+
+		return &testCredential{
+			Username: username,
+			Password: password,
+		}, nil
+	}
+
+	c, err := NewConnector(&testStore{
+		Getter:    getFn,
+		Refresher: getFn,
+	}, "driver", &Config{
+		Host: host,
+		Port: port,
+		DB:   "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make(chan error, concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			_, err := c.Connect(context.Background())
+			errs <- err
+		}()
+	}
+
+	for i := 0; i < concurrency; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if getCalled.Load() != 1 {
+		t.Fatalf("expected Get func to have been called once but it was called %d times", getCalled.Load())
+	}
+}
+
+func TestConnectorDeduplicatesConcurrentRefreshes(t *testing.T) {
+	unregisterAllDrivers()
+	concurrency := 10
+	newPassword := "baz"
+	d := &testConcurrentDriver{
+		goodPassword: newPassword,
+		opens:        concurrency,
+		ready:        make(chan struct{}),
+	}
+	if err := Register("driver", func() *Driver {
+		return &Driver{
+			Driver:    d,
+			Formatter: MysqlFormatter,
+			AuthError: errorTester(MysqlErrorText),
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var refreshCalled atomic.Int32
+
+	c, err := NewConnector(&testStore{
+		Getter: func(ctx context.Context) (Credentials, error) {
+			return &testCredential{
+				Username: username,
+				Password: password,
+			}, nil
+		},
+		Refresher: func(ctx context.Context) (Credentials, error) {
+			refreshCalled.Add(1)
+			// Give every other connection attempt a chance to fail and ask for a refresh too
+			time.Sleep(50 * time.Millisecond)
+
+			return &testCredential{
+				Username: username,
+				Password: newPassword,
+			}, nil
+		},
+	}, "driver", &Config{
+		Host: host,
+		Port: port,
+		DB:   "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make(chan error, concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			_, err := c.Connect(context.Background())
+			errs <- err
+		}()
+	}
+
+	for i := 0; i < concurrency; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if refreshCalled.Load() != 1 {
+		t.Fatalf("expected Refresh func to have been called once but it was called %d times", refreshCalled.Load())
+	}
+}
+
+func TestConnectorUsesRetryPolicy(t *testing.T) {
+	unregisterAllDrivers()
+	var connErr []error
+	maxCalled := 5
+	for i := 0; i < maxCalled; i++ {
+		connErr = append(connErr, errors.New(MysqlErrorText))
+	}
+
+	d := &testRetryingFailureDriver{
+		ConnErr:   connErr,
+		MaxCalled: maxCalled,
+	}
+
+	if err := Register("driver", func() *Driver {
+		return &Driver{
+			Driver:    d,
+			Formatter: MysqlFormatter,
+			AuthError: errorTester(MysqlErrorText),
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	getFn := func(ctx context.Context) (Credentials, error) {
+		return &testCredential{
+			Username: username,
+			Password: password,
+		}, nil
+	}
+
+	var delays []time.Duration
+
+	c, err := NewConnector(&testStore{
+		Getter:    getFn,
+		Refresher: getFn,
+	}, "driver", &Config{
+		Host: host,
+		Port: port,
+		DB:   "test",
+		// Retries should be ignored in favor of the policy
+		Retries: 10,
+		RetryPolicy: ExponentialBackoff{
+			Retries: 3,
+			Initial: time.Millisecond,
+		},
+		OnRetry: func(attempt int, delay time.Duration, err error) {
+			if attempt != len(delays)+1 {
+				t.Errorf("expected attempt %d but got %d", len(delays)+1, attempt)
+			}
+
+			if err == nil {
+				t.Error("expected the error causing the retry but got nil")
+			}
+
+			delays = append(delays, delay)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Connect(context.Background()); err == nil {
+		t.Fatal("expected an error but got nil")
+	}
+
+	expectedDelays := []time.Duration{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond}
+	if len(delays) != len(expectedDelays) {
+		t.Fatalf("expected %d retries but got %d", len(expectedDelays), len(delays))
+	}
+
+	for i, delay := range delays {
+		if delay != expectedDelays[i] {
+			t.Fatalf("expected delay for attempt %d to be %s but got %s", i+1, expectedDelays[i], delay)
+		}
+	}
+
+	if d.Called != len(expectedDelays)+1 {
+		t.Fatalf("expected driver.Open to have been called %d times but it was called %d times", len(expectedDelays)+1, d.Called)
+	}
+}
+
+func TestConnectorStopsRetryingWhenContextIsDone(t *testing.T) {
+	unregisterAllDrivers()
+	d := &testFailingDriver{
+		ConnErr: errors.New(MysqlErrorText),
+	}
+	if err := Register("driver", func() *Driver {
+		return &Driver{
+			Driver:    d,
+			Formatter: MysqlFormatter,
+			AuthError: errorTester(MysqlErrorText),
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	refreshCalled := 0
+
+	c, err := NewConnector(&testStore{
+		Getter: func(ctx context.Context) (Credentials, error) {
+			return &testCredential{
+				Username: username,
+				Password: password,
+			}, nil
+		},
+		Refresher: func(ctx context.Context) (Credentials, error) {
+			refreshCalled++
+
+			return &testCredential{
+				Username: username,
+				Password: password,
+			}, nil
+		},
+	}, "driver", &Config{
+		Host:        host,
+		Port:        port,
+		DB:          "test",
+		RetryPolicy: ConstantBackoff{Retries: 1, Delay: time.Hour},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := c.Connect(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected '%v' but got '%v' instead", context.DeadlineExceeded, err)
+	}
+
+	if refreshCalled != 0 {
+		t.Fatalf("expected Refresh func not to have been called but it was called %d times", refreshCalled)
+	}
+}
+
+type testCtxKey struct{}
+
+func TestConnectorUsesDriverContext(t *testing.T) {
+	unregisterAllDrivers()
+	d := &testDriver{}
+	dc := &testDriverConnector{driver: d}
+	d.Connector = dc
+	if err := Register("driver", func() *Driver {
+		return &Driver{
+			Driver:    d,
+			Formatter: MysqlFormatter,
+			AuthError: errorTester(MysqlErrorText),
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	getFn := func(ctx context.Context) (Credentials, error) {
+		return &testCredential{
+			Username: username,
+			Password: password,
+		}, nil
+	}
+
+	c, err := NewConnector(&testStore{
+		Getter:    getFn,
+		Refresher: getFn,
+	}, "driver", &Config{
+		Host: host,
+		Port: port,
+		DB:   "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.WithValue(context.Background(), testCtxKey{}, "value")
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.Connect(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if dc.ctx == nil || dc.ctx.Value(testCtxKey{}) != "value" {
+		t.Fatal("expected the context passed to Connect to reach the driver's connector")
+	}
+
+	if d.OpenConnectorCalled != 1 {
+		t.Fatalf("expected OpenConnector to have been called once but it was called %d times", d.OpenConnectorCalled)
+	}
+
+	if d.Called != 2 {
+		t.Fatalf("expected driver.Open to have been called twice but it was called %d times", d.Called)
+	}
+}
+
+type testFailingContextDriver struct {
+	testFailingDriver
+	OpenConnectorCalled int
+}
+
+func (d *testFailingContextDriver) OpenConnector(dsn string) (driver.Connector, error) {
+	d.OpenConnectorCalled++
+
+	return dsnConnector{driver: d, dsn: dsn}, nil
+}
+
+func TestConnectorOpensNewDriverConnectorForNewCredentials(t *testing.T) {
+	unregisterAllDrivers()
+	d := &testFailingContextDriver{
+		testFailingDriver: testFailingDriver{
+			ConnErr: errors.New(MysqlErrorText),
+		},
+	}
+	if err := Register("driver", func() *Driver {
+		return &Driver{
+			Driver:    d,
+			Formatter: MysqlFormatter,
+			AuthError: errorTester(MysqlErrorText),
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	getFn := func(ctx context.Context) (Credentials, error) {
+		return &testCredential{
+			Username: username,
+			Password: password,
+		}, nil
+	}
+
+	c, err := NewConnector(&testStore{
+		Getter:    getFn,
+		Refresher: rotatingCredentials(),
+	}, "driver", &Config{
+		Host: host,
+		Port: port,
+		DB:   "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if d.OpenConnectorCalled != 2 {
+		t.Fatalf("expected OpenConnector to have been called twice but it was called %d times", d.OpenConnectorCalled)
+	}
+}
+
+func TestConnectorConnectsWithNewTokens(t *testing.T) {
+	unregisterAllDrivers()
+	d := &testDriver{}
+	if err := Register("driver", func() *Driver {
+		return &Driver{
+			Driver:    d,
+			Formatter: MysqlFormatter,
+			AuthError: errorTester(MysqlErrorText),
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewConnector(&testStore{
+		Getter: func(ctx context.Context) (Credentials, error) {
+			return &testCredential{Username: username, Password: "first", Token: true}, nil
+		},
+		Refresher: func(ctx context.Context) (Credentials, error) {
+			return &testCredential{Username: username, Password: "second", Token: true}, nil
+		},
+	}, "driver", &Config{
+		Host: host,
+		Port: port,
+		DB:   "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	if _, err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.refresh(ctx, c.current().version); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	dsn := MysqlFormatter(username, "second", host, port, "test", nil)
+	if d.ConnStr != dsn {
+		t.Fatalf("expected %s but got %s instead", dsn, d.ConnStr)
+	}
+
+	if d.OpenConnectorCalled != 2 {
+		t.Fatalf("expected OpenConnector to have been called twice but it was called %d times", d.OpenConnectorCalled)
+	}
+}
+
+func TestConnectorReturnsOpenConnectorErrors(t *testing.T) {
+	unregisterAllDrivers()
+	connectorErr := errors.New("invalid dsn")
+	d := &testDriver{
+		ConnectorError: connectorErr,
+	}
+	if err := Register("driver", func() *Driver {
+		return &Driver{
+			Driver:    d,
+			Formatter: MysqlFormatter,
+			AuthError: errorTester(MysqlErrorText),
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	getFn := func(ctx context.Context) (Credentials, error) {
+		return &testCredential{
+			Username: username,
+			Password: password,
+		}, nil
+	}
+
+	c, err := NewConnector(&testStore{
+		Getter:    getFn,
+		Refresher: getFn,
+	}, "driver", &Config{
+		Host: host,
+		Port: port,
+		DB:   "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Connect(context.Background()); !errors.Is(err, connectorErr) {
+		t.Fatalf("expected '%v' but got '%v' instead", connectorErr, err)
+	}
+
+	if d.Called != 0 {
+		t.Fatalf("expected driver.Open not to have been called but it was called %d times", d.Called)
+	}
+}
+
+func TestConnectorCloseRevokesAndClosesStore(t *testing.T) {
+	unregisterAllDrivers()
+	d := &testDriver{Conn: &testConn{}}
+	if err := Register("driver", func() *Driver {
+		return &Driver{
+			Driver:    d,
+			Formatter: MysqlFormatter,
+			AuthError: errorTester(MysqlErrorText),
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var refreshCalled atomic.Int32
+
+	s := &testRevokingStore{
+		testStore: testStore{
+			Getter: func(ctx context.Context) (Credentials, error) {
+				return &testCredential{
+					Username:   username,
+					Password:   password,
+					Expiration: time.Now().Add(100 * time.Millisecond),
+				}, nil
+			},
+			Refresher: func(ctx context.Context) (Credentials, error) {
+				refreshCalled.Add(1)
+
+				return &testCredential{
+					Username: username,
+					Password: password,
+				}, nil
+			},
+		},
+	}
+
+	c, err := NewConnector(s, "driver", &Config{
+		Host: host,
+		Port: port,
+		DB:   "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := sql.OpenDB(c)
+
+	if err := db.PingContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// database/sql closes the connector when the DB is closed
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Revoked != 1 {
+		t.Fatalf("expected Revoke to have been called once but it was called %d times", s.Revoked)
+	}
+
+	if s.Closed != 1 {
+		t.Fatalf("expected Close to have been called once but it was called %d times", s.Closed)
+	}
+
+	// Closing again is a no-op
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Revoked != 1 || s.Closed != 1 {
+		t.Fatal("expected closing the connector twice not to revoke or close the store again")
+	}
+
+	if _, err := c.Connect(context.Background()); !errors.Is(err, ErrConnectorClosed) {
+		t.Fatalf("expected '%v' but got '%v' instead", ErrConnectorClosed, err)
+	}
+
+	// The background refresh scheduled for the expiring credentials should have been stopped
+	time.Sleep(200 * time.Millisecond)
+
+	if refreshCalled.Load() != 0 {
+		t.Fatalf("expected no background refresh after closing but Refresh was called %d times", refreshCalled.Load())
+	}
+}
+
+// testNotifyingStore is a testStore that also implements Notifier.
+type testNotifyingStore struct {
+	testStore
+	notify  func(Credentials)
+	stopped bool
+}
+
+func (ns *testNotifyingStore) Notify(fn func(Credentials)) func() {
+	ns.notify = fn
+
+	return func() {
+		ns.stopped = true
+	}
+}
+
+func TestConnectorUsesCredentialsSentByNotifier(t *testing.T) {
+	unregisterAllDrivers()
+	d := &testDriver{Conn: &testConn{}}
+	if err := Register("driver", func() *Driver {
+		return &Driver{
+			Driver:    d,
+			Formatter: MysqlFormatter,
+			AuthError: errorTester(MysqlErrorText),
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &testNotifyingStore{
+		testStore: testStore{
+			Getter: func(ctx context.Context) (Credentials, error) {
+				return &testCredential{Username: username, Password: password}, nil
+			},
+		},
+	}
+
+	o := &testObserver{}
+
+	c, err := NewConnector(s, "driver", &Config{
+		Host:     host,
+		Port:     port,
+		DB:       "test",
+		Observer: o,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.notify == nil {
+		t.Fatal("expected the connector to subscribe to the store")
+	}
+
+	if _, err := c.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	s.notify(&testCredential{Username: username, Password: "baz"})
+	// Invalid credentials are ignored and the same credentials again aren't new.
+	s.notify(&testCredential{Username: username})
+	s.notify(&testCredential{Username: username, Password: "baz"})
+
+	if _, err := c.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := username + ":baz@"; !strings.HasPrefix(d.ConnStr, expected) {
+		t.Fatalf("expected '%s' but got '%s' instead", expected, d.ConnStr)
+	}
+
+	if d.Called != 2 {
+		t.Fatalf("expected driver.Open to have been called twice but it was called %d times", d.Called)
+	}
+
+	expected := []string{"get <nil>", "new credentials 1", "connect 1", "new credentials 2", "connect 2"}
+	if events := o.Events(); !reflect.DeepEqual(events, expected) {
+		t.Fatalf("expected '%v' but got '%v' instead", expected, events)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !s.stopped {
+		t.Fatal("expected the connector to unsubscribe from the store when closed")
+	}
+}
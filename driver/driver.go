@@ -148,8 +148,19 @@ func pgxV5Driver() *Driver {
 
 func pqDriver() *Driver {
 	return &Driver{
-		Driver:    &pq.Driver{},
+		Driver:    &pqContextDriver{},
 		Formatter: PgFormatter,
 		AuthError: PostgreSQLAuthError,
 	}
 }
+
+// pqContextDriver adds driver.DriverContext support to lib/pq, which only exposes it through
+// pq.NewConnector rather than on its Driver.
+type pqContextDriver struct {
+	pq.Driver
+}
+
+// OpenConnector implements the driver.DriverContext interface.
+func (*pqContextDriver) OpenConnector(dsn string) (driver.Connector, error) {
+	return pq.NewConnector(dsn)
+}
@@ -1,6 +1,7 @@
 package driver
 
 import (
+	"database/sql/driver"
 	"errors"
 	"strings"
 	"testing"
@@ -8,7 +9,6 @@ import (
 	"github.com/go-sql-driver/mysql"
 	"github.com/jackc/pgx/v4/stdlib"
 	v5 "github.com/jackc/pgx/v5/stdlib"
-	"github.com/lib/pq"
 )
 
 const (
@@ -186,9 +186,9 @@ func TestCanCreateAllDrivers(t *testing.T) {
 				)
 			}
 		case "pq":
-			if driver, ok := d.Driver.(*pq.Driver); !ok {
+			if driver, ok := d.Driver.(*pqContextDriver); !ok {
 				t.Fatalf(
-					"expected pq factory to create a *pq.Driver but got a %T instead",
+					"expected pq factory to create a pq driver but got a %T instead",
 					driver,
 				)
 			}
@@ -202,3 +202,18 @@ func TestCanCreateAllDrivers(t *testing.T) {
 		}
 	}
 }
+
+func TestAllDriversImplementDriverContext(t *testing.T) {
+	for name, f := range availableDrivers {
+		d := f()
+
+		dc, ok := d.Driver.(driver.DriverContext)
+		if !ok {
+			t.Fatalf("expected %s driver to implement driver.DriverContext", name)
+		}
+
+		if _, err := dc.OpenConnector(d.Formatter("user", "pass", "localhost", 5432, "db", nil)); err != nil {
+			t.Fatalf("expected %s driver to open a connector but got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package driver
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// logObserver is an Observer that logs the credential lifecycle. Routine events are logged at debug
+// level, refreshes at info level, rejected credentials at warn level and failures at error level.
+type logObserver struct {
+	NopObserver
+	logger *slog.Logger
+}
+
+// newLogObserver returns an Observer logging to logger or nil if logger is nil.
+func newLogObserver(logger *slog.Logger) Observer {
+	if logger == nil {
+		return nil
+	}
+
+	return &logObserver{logger: logger}
+}
+
+// OnGet implements the Observer interface.
+func (o *logObserver) OnGet(ctx context.Context, err error, d time.Duration) {
+	if err != nil {
+		o.logger.ErrorContext(ctx, "failed to get credentials from store", "duration", d, "error", err)
+
+		return
+	}
+
+	o.logger.DebugContext(ctx, "got credentials from store", "duration", d)
+}
+
+// OnRefreshStart implements the Observer interface.
+func (o *logObserver) OnRefreshStart(ctx context.Context) {
+	o.logger.DebugContext(ctx, "refreshing credentials")
+}
+
+// OnRefreshDone implements the Observer interface.
+func (o *logObserver) OnRefreshDone(ctx context.Context, err error, d time.Duration) {
+	if err != nil {
+		o.logger.ErrorContext(ctx, "failed to refresh credentials", "duration", d, "error", err)
+
+		return
+	}
+
+	o.logger.InfoContext(ctx, "refreshed credentials", "duration", d)
+}
+
+// OnAuthFailure implements the Observer interface.
+func (o *logObserver) OnAuthFailure(ctx context.Context, err error, attempt int) {
+	o.logger.WarnContext(ctx, "database rejected credentials", "attempt", attempt, "error", err)
+}
+
+// OnRetriesExhausted implements the Observer interface.
+func (o *logObserver) OnRetriesExhausted(ctx context.Context, err error, retries int) {
+	o.logger.ErrorContext(ctx, "giving up connecting after retries", "retries", retries, "error", err)
+}
+
+// OnConnect implements the Observer interface.
+func (o *logObserver) OnConnect(ctx context.Context, generation uint64) {
+	o.logger.DebugContext(ctx, "opened connection", "generation", generation)
+}
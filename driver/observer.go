@@ -0,0 +1,118 @@
+package driver
+
+import (
+	"context"
+	"time"
+)
+
+// Observer is notified of the lifecycle of the credentials used by a Connector, which makes it
+// possible to log, trace or record metrics for them. Observers never receive the credentials
+// themselves so they can't leak passwords.
+//
+// Callbacks are made synchronously from the goroutine doing the work so they should return quickly.
+// Embed NopObserver to only implement the callbacks you need.
+type Observer interface {
+	// OnGet is called after the Connector gets credentials from its Store for the first time, with
+	// the error, if any, and how long the Store took.
+	OnGet(ctx context.Context, err error, d time.Duration)
+	// OnRefreshStart is called before the Connector asks its Store to refresh its credentials,
+	// either because the database rejected them or ahead of their expiry.
+	OnRefreshStart(ctx context.Context)
+	// OnRefreshDone is called after a refresh with the error, if any, and how long the Store took.
+	OnRefreshDone(ctx context.Context, err error, d time.Duration)
+	// OnAuthFailure is called every time the database rejects the credentials with the error it
+	// returned and the attempt that failed, starting at 0 for the first connection attempt.
+	OnAuthFailure(ctx context.Context, err error, attempt int)
+	// OnRetriesExhausted is called when the RetryPolicy gives up with the last error and how many
+	// retries were made.
+	OnRetriesExhausted(ctx context.Context, err error, retries int)
+	// OnNewCredentials is called when the Connector starts using credentials with a different
+	// username or password, either from its Store or pushed by a Notifier, with their generation and
+	// when the Connector received them. A new login token for the same user keeps the generation of
+	// the credentials it replaces.
+	OnNewCredentials(ctx context.Context, generation uint64, issued time.Time)
+	// OnConnect is called after a connection is opened with the generation of the credentials it
+	// was opened with, which increases every time they are replaced with a different username or
+	// password.
+	OnConnect(ctx context.Context, generation uint64)
+}
+
+// NopObserver is an Observer that does nothing.
+type NopObserver struct{}
+
+var _ Observer = NopObserver{}
+
+// OnGet implements the Observer interface.
+func (NopObserver) OnGet(context.Context, error, time.Duration) {}
+
+// OnRefreshStart implements the Observer interface.
+func (NopObserver) OnRefreshStart(context.Context) {}
+
+// OnRefreshDone implements the Observer interface.
+func (NopObserver) OnRefreshDone(context.Context, error, time.Duration) {}
+
+// OnAuthFailure implements the Observer interface.
+func (NopObserver) OnAuthFailure(context.Context, error, int) {}
+
+// OnRetriesExhausted implements the Observer interface.
+func (NopObserver) OnRetriesExhausted(context.Context, error, int) {}
+
+// OnNewCredentials implements the Observer interface.
+func (NopObserver) OnNewCredentials(context.Context, uint64, time.Time) {}
+
+// OnConnect implements the Observer interface.
+func (NopObserver) OnConnect(context.Context, uint64) {}
+
+// MultiObserver notifies each of its Observers in order.
+type MultiObserver []Observer
+
+var _ Observer = MultiObserver(nil)
+
+// OnGet implements the Observer interface.
+func (m MultiObserver) OnGet(ctx context.Context, err error, d time.Duration) {
+	for _, o := range m {
+		o.OnGet(ctx, err, d)
+	}
+}
+
+// OnRefreshStart implements the Observer interface.
+func (m MultiObserver) OnRefreshStart(ctx context.Context) {
+	for _, o := range m {
+		o.OnRefreshStart(ctx)
+	}
+}
+
+// OnRefreshDone implements the Observer interface.
+func (m MultiObserver) OnRefreshDone(ctx context.Context, err error, d time.Duration) {
+	for _, o := range m {
+		o.OnRefreshDone(ctx, err, d)
+	}
+}
+
+// OnAuthFailure implements the Observer interface.
+func (m MultiObserver) OnAuthFailure(ctx context.Context, err error, attempt int) {
+	for _, o := range m {
+		o.OnAuthFailure(ctx, err, attempt)
+	}
+}
+
+// OnRetriesExhausted implements the Observer interface.
+func (m MultiObserver) OnRetriesExhausted(ctx context.Context, err error, retries int) {
+	for _, o := range m {
+		o.OnRetriesExhausted(ctx, err, retries)
+	}
+}
+
+// OnNewCredentials implements the Observer interface.
+func (m MultiObserver) OnNewCredentials(ctx context.Context, generation uint64, issued time.Time) {
+	for _, o := range m {
+		o.OnNewCredentials(ctx, generation, issued)
+	}
+}
+
+// OnConnect implements the Observer interface.
+func (m MultiObserver) OnConnect(ctx context.Context, generation uint64) {
+	for _, o := range m {
+		o.OnConnect(ctx, generation)
+	}
+}
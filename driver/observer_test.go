@@ -0,0 +1,250 @@
+package driver
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testObserver struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (o *testObserver) record(format string, args ...any) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.events = append(o.events, fmt.Sprintf(format, args...))
+}
+
+func (o *testObserver) Events() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return append([]string(nil), o.events...)
+}
+
+func (o *testObserver) OnGet(_ context.Context, err error, _ time.Duration) {
+	o.record("get %v", err)
+}
+
+func (o *testObserver) OnRefreshStart(_ context.Context) {
+	o.record("refresh start")
+}
+
+func (o *testObserver) OnRefreshDone(_ context.Context, err error, _ time.Duration) {
+	o.record("refresh done %v", err)
+}
+
+func (o *testObserver) OnAuthFailure(_ context.Context, _ error, attempt int) {
+	o.record("auth failure %d", attempt)
+}
+
+func (o *testObserver) OnRetriesExhausted(_ context.Context, _ error, retries int) {
+	o.record("retries exhausted %d", retries)
+}
+
+func (o *testObserver) OnNewCredentials(_ context.Context, generation uint64, _ time.Time) {
+	o.record("new credentials %d", generation)
+}
+
+func (o *testObserver) OnConnect(_ context.Context, generation uint64) {
+	o.record("connect %d", generation)
+}
+
+// newObservedConnector creates a Connector whose driver fails to authenticate failures times.
+func newObservedConnector(t *testing.T, failures int, cfg *Config) *Connector {
+	t.Helper()
+
+	unregisterAllDrivers()
+
+	var d driver.Driver = &testDriver{}
+
+	if failures > 0 {
+		connErr := make([]error, failures)
+		for i := range connErr {
+			connErr[i] = errors.New(MysqlErrorText)
+		}
+
+		d = &testRetryingFailureDriver{ConnErr: connErr, MaxCalled: failures}
+	}
+
+	if err := Register("driver", func() *Driver {
+		return &Driver{
+			Driver:    d,
+			Formatter: MysqlFormatter,
+			AuthError: errorTester(MysqlErrorText),
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	getCreds := func(ctx context.Context) (Credentials, error) {
+		return &testCredential{
+			Username: username,
+			Password: password,
+		}, nil
+	}
+
+	c, err := NewConnector(&testStore{
+		Getter:    getCreds,
+		Refresher: rotatingCredentials(),
+	}, "driver", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func TestConnectorNotifiesObserver(t *testing.T) {
+	testCases := []struct {
+		description string
+		failures    int
+		retries     int
+		expectErr   bool
+		expected    []string
+	}{
+		{
+			description: "connects first time",
+			failures:    0,
+			retries:     2,
+			expected: []string{
+				"get <nil>",
+				"new credentials 1",
+				"connect 1",
+			},
+		},
+		{
+			description: "connects after retrying",
+			failures:    2,
+			retries:     3,
+			expected: []string{
+				"get <nil>",
+				"new credentials 1",
+				"auth failure 0",
+				"refresh start",
+				"refresh done <nil>",
+				"new credentials 2",
+				"auth failure 1",
+				"refresh start",
+				"refresh done <nil>",
+				"new credentials 3",
+				"connect 3",
+			},
+		},
+		{
+			description: "exhausts retries",
+			failures:    5,
+			retries:     2,
+			expectErr:   true,
+			expected: []string{
+				"get <nil>",
+				"new credentials 1",
+				"auth failure 0",
+				"refresh start",
+				"refresh done <nil>",
+				"new credentials 2",
+				"auth failure 1",
+				"refresh start",
+				"refresh done <nil>",
+				"new credentials 3",
+				"auth failure 2",
+				"retries exhausted 2",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			o := &testObserver{}
+
+			c := newObservedConnector(t, tc.failures, &Config{
+				Host:     host,
+				Port:     port,
+				DB:       "test",
+				Retries:  tc.retries,
+				Observer: o,
+			})
+
+			_, err := c.Connect(context.Background())
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error '%v' but got '%v' instead", tc.expectErr, err)
+			}
+
+			if events := o.Events(); !reflect.DeepEqual(events, tc.expected) {
+				t.Fatalf("expected '%v' but got '%v' instead", tc.expected, events)
+			}
+		})
+	}
+}
+
+func TestConnectorNotifiesObserverOfStoreErrors(t *testing.T) {
+	unregisterAllDrivers()
+
+	if err := Register("driver", func() *Driver {
+		return &Driver{
+			Driver:    &testDriver{},
+			Formatter: MysqlFormatter,
+			AuthError: errorTester(MysqlErrorText),
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	o := &testObserver{}
+
+	c, err := NewConnector(&testStore{
+		Getter: func(ctx context.Context) (Credentials, error) {
+			return &testCredential{Username: username}, nil
+		},
+	}, "driver", &Config{Observer: o})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Connect(context.Background()); !errors.Is(err, ErrMissingPassword) {
+		t.Fatalf("expected '%v' but got '%v' instead", ErrMissingPassword, err)
+	}
+
+	expected := []string{"get " + ErrMissingPassword.Error()}
+	if events := o.Events(); !reflect.DeepEqual(events, expected) {
+		t.Fatalf("expected '%v' but got '%v' instead", expected, events)
+	}
+}
+
+func TestMultiObserverNotifiesEveryObserver(t *testing.T) {
+	first, second := &testObserver{}, &testObserver{}
+	m := MultiObserver{first, second, NopObserver{}}
+
+	ctx := context.Background()
+	m.OnGet(ctx, nil, time.Second)
+	m.OnRefreshStart(ctx)
+	m.OnRefreshDone(ctx, nil, time.Second)
+	m.OnAuthFailure(ctx, nil, 1)
+	m.OnRetriesExhausted(ctx, nil, 2)
+	m.OnNewCredentials(ctx, 3, time.Now())
+	m.OnConnect(ctx, 3)
+
+	expected := []string{
+		"get <nil>",
+		"refresh start",
+		"refresh done <nil>",
+		"auth failure 1",
+		"retries exhausted 2",
+		"new credentials 3",
+		"connect 3",
+	}
+
+	for _, o := range []*testObserver{first, second} {
+		if events := o.Events(); !reflect.DeepEqual(events, expected) {
+			t.Fatalf("expected '%v' but got '%v' instead", expected, events)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package driver
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Redacted replaces secrets in redacted output.
+const Redacted = "***"
+
+//nolint:gochecknoglobals
+var kvPassword = regexp.MustCompile(`(?i)(\bpassword\s*=\s*)('(?:[^'\\]|\\.)*'|\S*)`)
+
+// RedactDSN masks the password in a connection string so it can be logged. It understands the
+// output of PgFormatter, PgKVFormatter and MysqlFormatter. Connection strings it can't parse are
+// redacted entirely rather than risk leaking a password.
+func RedactDSN(dsn string) string {
+	if strings.Contains(dsn, "://") {
+		return redactURI(dsn)
+	}
+
+	if kvPassword.MatchString(dsn) {
+		return kvPassword.ReplaceAllString(dsn, "${1}"+Redacted)
+	}
+
+	if cfg, err := mysql.ParseDSN(dsn); err == nil {
+		if cfg.Passwd != "" {
+			cfg.Passwd = Redacted
+		}
+
+		return cfg.FormatDSN()
+	}
+
+	return Redacted
+}
+
+// redactURI masks the password in the user info and query of a connection URI. They are put back
+// together by hand because url.URL would escape the mask.
+func redactURI(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return Redacted
+	}
+
+	u.RawQuery = redactQuery(u.RawQuery)
+
+	user := u.User
+	if _, ok := user.Password(); !ok {
+		return u.String()
+	}
+
+	u.User = nil
+	prefix := u.Scheme + "://"
+
+	return prefix + url.User(user.Username()).String() + ":" + Redacted + "@" + strings.TrimPrefix(u.String(), prefix)
+}
+
+// redactQuery masks password parameters in a query string without reordering it.
+func redactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return rawQuery
+	}
+
+	params := strings.Split(rawQuery, "&")
+	for i, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		if k, err := url.QueryUnescape(key); err == nil && strings.EqualFold(k, "password") {
+			params[i] = key + "=" + Redacted
+		}
+	}
+
+	return strings.Join(params, "&")
+}
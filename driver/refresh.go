@@ -0,0 +1,257 @@
+package driver
+
+import (
+	"context"
+	"time"
+)
+
+// snapshot is the Connector's credentials at one point in time. version counts every set of
+// credentials the Store has returned while generation only counts the ones that retire pooled
+// connections.
+type snapshot struct {
+	creds      Credentials
+	version    uint64
+	generation uint64
+}
+
+// flight is a single call to the Store shared by every caller that needs its result.
+type flight struct {
+	done chan struct{}
+	snapshot
+	err error
+}
+
+// wait blocks until the flight lands or ctx is done.
+func (f *flight) wait(ctx context.Context) (snapshot, error) {
+	select {
+	case <-f.done:
+		return f.snapshot, f.err
+	case <-ctx.Done():
+		return snapshot{}, ctx.Err()
+	}
+}
+
+// credentials returns the current credentials, getting them from the Store if the Connector doesn't
+// have any yet.
+func (c *Connector) credentials(ctx context.Context) (snapshot, error) {
+	c.mu.Lock()
+	if c.creds != nil {
+		s := c.snapshot()
+		c.mu.Unlock()
+
+		return s, nil
+	}
+
+	f := c.join(context.WithoutCancel(ctx), c.get)
+	c.mu.Unlock()
+
+	return f.wait(ctx)
+}
+
+// refresh replaces the credentials of version stale with new ones from the Store. If they have
+// already been replaced, the current credentials are returned without calling the Store again.
+func (c *Connector) refresh(ctx context.Context, stale uint64) (snapshot, error) {
+	c.mu.Lock()
+	if c.creds != nil && c.version != stale {
+		s := c.snapshot()
+		c.mu.Unlock()
+
+		return s, nil
+	}
+
+	f := c.join(context.WithoutCancel(ctx), c.refreshStore)
+	c.mu.Unlock()
+
+	return f.wait(ctx)
+}
+
+// snapshot returns the current credentials. It must be called with c.mu held.
+func (c *Connector) snapshot() snapshot {
+	return snapshot{creds: c.creds, version: c.version, generation: c.generation}
+}
+
+// join returns the in-progress flight or starts a new one calling fn. The flight runs with its own
+// context so one caller giving up doesn't fail the call for everyone else waiting on it. It must be
+// called with c.mu held.
+func (c *Connector) join(ctx context.Context, fn func(context.Context) (Credentials, error)) *flight {
+	if c.flight != nil {
+		return c.flight
+	}
+
+	f := &flight{done: make(chan struct{})}
+	c.flight = f
+
+	go func() {
+		creds, err := fn(ctx)
+		issued := time.Now()
+
+		c.mu.Lock()
+		changed := err == nil && c.replaceCredentials(creds)
+		f.snapshot, f.err = c.snapshot(), err
+		c.flight = nil
+		c.mu.Unlock()
+
+		if changed {
+			c.observer.OnNewCredentials(ctx, f.generation, issued)
+		}
+
+		close(f.done)
+	}()
+
+	return f
+}
+
+// get gets credentials from the Store and validates them.
+func (c *Connector) get(ctx context.Context) (Credentials, error) {
+	start := time.Now()
+
+	creds, err := c.store.Get(ctx)
+	if err == nil {
+		err = ValidateCredentials(creds)
+	}
+
+	c.observer.OnGet(ctx, err, time.Since(start))
+
+	return creds, err
+}
+
+// refreshStore refreshes credentials from the Store and validates them.
+func (c *Connector) refreshStore(ctx context.Context) (Credentials, error) {
+	c.observer.OnRefreshStart(ctx)
+
+	start := time.Now()
+
+	creds, err := c.store.Refresh(ctx)
+	if err == nil {
+		err = ValidateCredentials(creds)
+	}
+
+	c.observer.OnRefreshDone(ctx, err, time.Since(start))
+
+	return creds, err
+}
+
+// notified replaces the current credentials with ones pushed by a Store that implements Notifier.
+// Connections opened with different credentials are retired like they are after a refresh.
+func (c *Connector) notified(creds Credentials) {
+	if err := ValidateCredentials(creds); err != nil {
+		if c.logger != nil {
+			c.logger.Warn("ignoring invalid credentials sent by store", "error", err)
+		}
+
+		return
+	}
+
+	issued := time.Now()
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+
+		return
+	}
+
+	changed := c.replaceCredentials(creds)
+	gen := c.generation
+	c.mu.Unlock()
+
+	if c.logger != nil {
+		c.logger.Debug("store sent new credentials", "generation", gen)
+	}
+
+	if changed {
+		c.observer.OnNewCredentials(context.Background(), gen, issued)
+	}
+}
+
+// replaceCredentials makes creds the current credentials and moves the version on. The generation
+// only moves on when the username or password changes, so stores that return the same credentials
+// again, like a static store, don't retire every pooled connection. New login tokens for the same
+// user, like RDS IAM tokens, don't retire them either, but new connections are opened with them. It
+// reports whether the username or password changed and must be called with c.mu held.
+func (c *Connector) replaceCredentials(creds Credentials) bool {
+	var changed, retire bool
+
+	switch {
+	case c.creds == nil || creds.GetUsername() != c.creds.GetUsername():
+		changed, retire = true, true
+	case creds.GetPassword() != c.creds.GetPassword():
+		changed, retire = true, !isToken(creds)
+	}
+
+	c.version++
+	if retire {
+		c.generation++
+	}
+
+	c.creds = creds
+	c.scheduleRefresh(creds)
+
+	return changed
+}
+
+// scheduleRefresh arranges for ExpiringCredentials to be refreshed in the background shortly before
+// they expire. Credentials expiring at the same time as the current ones keep the refresh already
+// scheduled for them. It must be called with c.mu held.
+func (c *Connector) scheduleRefresh(creds Credentials) {
+	exp := ExpiresAt(creds)
+	if c.closed || exp.Equal(c.scheduledExpiry) {
+		return
+	}
+
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+
+	c.scheduledExpiry = exp
+	if exp.IsZero() {
+		return
+	}
+
+	remaining := time.Until(exp)
+
+	lead := c.cfg.RefreshBefore
+	if half := remaining / 2; lead > half {
+		lead = half
+	}
+
+	c.timer = time.AfterFunc(remaining-lead, func() {
+		c.backgroundRefresh(exp)
+	})
+}
+
+// backgroundRefresh refreshes the credentials expiring at exp ahead of their expiry so new
+// connections don't have to fail authentication before picking up new credentials.
+func (c *Connector) backgroundRefresh(exp time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), backgroundRefreshTimeout)
+	defer cancel()
+
+	c.mu.Lock()
+	if c.closed || !c.scheduledExpiry.Equal(exp) {
+		c.mu.Unlock()
+
+		return
+	}
+
+	f := c.flight
+	if f == nil {
+		f = c.join(ctx, c.refreshStore)
+	}
+	c.mu.Unlock()
+
+	_, _ = f.wait(ctx)
+
+	// The refresh made no progress if it failed or the Store returned credentials expiring at the
+	// same time, like a LastKnownGood store serving cached credentials while the store it wraps is
+	// unavailable. The current credentials are still usable so try again shortly. Once they've
+	// expired we stop and let Connect refresh them instead.
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.closed && c.scheduledExpiry.Equal(exp) && time.Until(exp) > c.retryDelay {
+		c.timer = time.AfterFunc(c.retryDelay, func() {
+			c.backgroundRefresh(exp)
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package driver
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy decides whether and when the Connector retries a connection that failed to
+// authenticate. Every retry refreshes the credentials before connecting again.
+type RetryPolicy interface {
+	// Backoff is called before each retry with the 1-based retry attempt and the delay returned for
+	// the previous attempt, which is zero for the first one. It returns how long to wait before
+	// retrying and false once no more retries should be made.
+	Backoff(attempt int, previous time.Duration) (time.Duration, bool)
+}
+
+// RetryHook is called before each retry with the attempt number, how long the Connector will wait
+// before retrying, and the error that caused the retry.
+type RetryHook func(attempt int, delay time.Duration, err error)
+
+// ConstantBackoff retries up to Retries times, waiting Delay between each attempt.
+type ConstantBackoff struct {
+	Retries int
+	Delay   time.Duration
+}
+
+// Backoff implements the RetryPolicy interface.
+func (b ConstantBackoff) Backoff(attempt int, _ time.Duration) (time.Duration, bool) {
+	return b.Delay, attempt <= b.Retries
+}
+
+// ExponentialBackoff retries up to Retries times, starting with a delay of Initial and multiplying
+// it by Multiplier for each subsequent attempt up to a maximum of Max. A Multiplier of 1 or less
+// defaults to 2 and a zero Max means the delay is uncapped.
+type ExponentialBackoff struct {
+	Retries    int
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+}
+
+// Backoff implements the RetryPolicy interface.
+func (b ExponentialBackoff) Backoff(attempt int, previous time.Duration) (time.Duration, bool) {
+	if attempt > b.Retries {
+		return 0, false
+	}
+
+	multiplier := b.Multiplier
+	if multiplier <= 1 {
+		multiplier = 2
+	}
+
+	delay := b.Initial
+	if previous > 0 {
+		delay = time.Duration(float64(previous) * multiplier)
+	}
+
+	if b.Max > 0 && delay > b.Max {
+		delay = b.Max
+	}
+
+	return delay, true
+}
+
+// DecorrelatedJitterBackoff retries up to Retries times, waiting a random delay between Base and
+// three times the previous delay, capped at Cap. Spreading retries out like this stops many clients
+// that failed at the same time from retrying in lockstep.
+// See https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/
+type DecorrelatedJitterBackoff struct {
+	Retries int
+	Base    time.Duration
+	Cap     time.Duration
+}
+
+// Backoff implements the RetryPolicy interface.
+func (b DecorrelatedJitterBackoff) Backoff(attempt int, previous time.Duration) (time.Duration, bool) {
+	if attempt > b.Retries {
+		return 0, false
+	}
+
+	upper := 3 * max(previous, b.Base)
+
+	delay := b.Base
+	if upper > b.Base {
+		delay += time.Duration(rand.Int64N(int64(upper - b.Base))) //nolint:gosec
+	}
+
+	if b.Cap > 0 && delay > b.Cap {
+		delay = b.Cap
+	}
+
+	return delay, true
+}
+
+// sleep waits for d to elapse or for ctx to be done, whichever happens first.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
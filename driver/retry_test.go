@@ -0,0 +1,125 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicies(t *testing.T) {
+	testCases := []struct {
+		name     string
+		policy   RetryPolicy
+		expected []time.Duration
+	}{
+		{
+			name:     "constant - no retries",
+			policy:   ConstantBackoff{},
+			expected: []time.Duration{},
+		},
+		{
+			name:     "constant",
+			policy:   ConstantBackoff{Retries: 3, Delay: time.Second},
+			expected: []time.Duration{time.Second, time.Second, time.Second},
+		},
+		{
+			name: "exponential - default multiplier",
+			policy: ExponentialBackoff{
+				Retries: 4,
+				Initial: 100 * time.Millisecond,
+			},
+			expected: []time.Duration{
+				100 * time.Millisecond,
+				200 * time.Millisecond,
+				400 * time.Millisecond,
+				800 * time.Millisecond,
+			},
+		},
+		{
+			name: "exponential - capped",
+			policy: ExponentialBackoff{
+				Retries:    4,
+				Initial:    100 * time.Millisecond,
+				Max:        500 * time.Millisecond,
+				Multiplier: 3,
+			},
+			expected: []time.Duration{
+				100 * time.Millisecond,
+				300 * time.Millisecond,
+				500 * time.Millisecond,
+				500 * time.Millisecond,
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var delay time.Duration
+			delays := make([]time.Duration, 0)
+
+			for attempt := 1; ; attempt++ {
+				var retry bool
+				if delay, retry = testCase.policy.Backoff(attempt, delay); !retry {
+					break
+				}
+
+				delays = append(delays, delay)
+			}
+
+			if len(delays) != len(testCase.expected) {
+				t.Fatalf("expected %d retries but got %d", len(testCase.expected), len(delays))
+			}
+
+			for i, d := range delays {
+				if d != testCase.expected[i] {
+					t.Fatalf("expected delay for attempt %d to be %s but got %s", i+1, testCase.expected[i], d)
+				}
+			}
+		})
+	}
+}
+
+func TestDecorrelatedJitterBackoffStaysWithinBounds(t *testing.T) {
+	policy := DecorrelatedJitterBackoff{
+		Retries: 100,
+		Base:    10 * time.Millisecond,
+		Cap:     time.Second,
+	}
+
+	var delay time.Duration
+
+	for attempt := 1; attempt <= policy.Retries; attempt++ {
+		previous := delay
+
+		var retry bool
+		if delay, retry = policy.Backoff(attempt, previous); !retry {
+			t.Fatalf("expected attempt %d to be retried", attempt)
+		}
+
+		if delay < policy.Base || delay > policy.Cap {
+			t.Fatalf("expected delay to be between %s and %s but got %s", policy.Base, policy.Cap, delay)
+		}
+
+		if upper := 3 * max(previous, policy.Base); delay > upper {
+			t.Fatalf("expected delay to be at most %s but got %s", upper, delay)
+		}
+	}
+
+	if _, retry := policy.Backoff(policy.Retries+1, delay); retry {
+		t.Fatal("expected no more retries once Retries was exceeded")
+	}
+}
+
+func TestSleepRespectsContextCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := sleep(ctx, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected '%v' but got '%v' instead", context.Canceled, err)
+	}
+
+	if err := sleep(context.Background(), time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -2,6 +2,7 @@ package driver
 
 import (
 	"context"
+	"time"
 )
 
 // Store represents a mechanism for retrieving Credentials.
@@ -10,8 +11,64 @@ type Store interface {
 	Refresh(ctx context.Context) (Credentials, error)
 }
 
+// Revoker is an optional interface for Stores that can revoke the credentials they've issued. The
+// Connector revokes them when it is closed so short-lived processes don't leave credentials behind.
+// Stores that hold other resources can also implement io.Closer, which the Connector calls after
+// Revoke.
+type Revoker interface {
+	Revoke(ctx context.Context) error
+}
+
+// Notifier is an optional interface for Stores that learn about new credentials on their own, like a
+// Store watching a file that Vault Agent rewrites. The Connector subscribes when it is created and
+// switches to the credentials it is sent straight away instead of waiting for the database to reject
+// the old ones.
+type Notifier interface {
+	// Notify registers fn to be called with new credentials. The returned function unregisters it
+	// and is called when the Connector is closed.
+	Notify(fn func(Credentials)) (stop func())
+}
+
 // Credentials represents an abstraction over a username and password.
 type Credentials interface {
 	GetUsername() string
 	GetPassword() string
 }
+
+// ExpiringCredentials is an optional interface for Credentials with a known lifetime, like Vault
+// leases or RDS IAM tokens. When a Store returns ExpiringCredentials the Connector refreshes them in
+// the background before they expire rather than waiting for the database to reject them.
+type ExpiringCredentials interface {
+	Credentials
+	// ExpiresAt returns the time the credentials stop being valid. A zero time means the expiry is
+	// unknown and the credentials will only be refreshed after an authentication error.
+	ExpiresAt() time.Time
+}
+
+// TokenCredentials is an optional interface for Credentials whose password is a login token, like an
+// RDS IAM authentication token, that the database only checks when a connection is opened. Sessions
+// opened with an earlier token stay valid, so the Connector only retires pooled connections when the
+// username changes rather than every time the store issues a new token.
+type TokenCredentials interface {
+	Credentials
+	// IsToken reports whether the password is a login token.
+	IsToken() bool
+}
+
+// ExpiresAt returns the expiry of a set of credentials or a zero time if they don't implement
+// ExpiringCredentials.
+func ExpiresAt(creds Credentials) time.Time {
+	ec, ok := creds.(ExpiringCredentials)
+	if !ok {
+		return time.Time{}
+	}
+
+	return ec.ExpiresAt()
+}
+
+// isToken reports whether the password of a set of credentials is a login token.
+func isToken(creds Credentials) bool {
+	tc, ok := creds.(TokenCredentials)
+
+	return ok && tc.IsToken()
+}
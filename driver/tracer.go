@@ -0,0 +1,35 @@
+package driver
+
+import (
+	"context"
+)
+
+// ConnectInfo describes what a Connector connects to. It never contains credentials.
+type ConnectInfo struct {
+	Driver string
+	Host   string
+	Port   int
+	DB     string
+}
+
+// Tracer traces the work a Connector does to open a connection. StartConnect is called when
+// Connect starts and StartOpen before each attempt to open a connection with the underlying driver.
+// Both return the context for the work they cover, which is passed on to the Store and the driver,
+// and a function that is called once that work is done.
+type Tracer interface {
+	StartConnect(ctx context.Context, info ConnectInfo) (context.Context, func(err error))
+	// StartOpen is called with the attempt, starting at 0 for the first one. The function it returns
+	// is told whether the attempt failed because the database rejected the credentials.
+	StartOpen(ctx context.Context, attempt int) (context.Context, func(err error, authFailure bool))
+}
+
+// nopTracer is the Tracer used when Config.Tracer isn't set.
+type nopTracer struct{}
+
+func (nopTracer) StartConnect(ctx context.Context, _ ConnectInfo) (context.Context, func(error)) {
+	return ctx, func(error) {}
+}
+
+func (nopTracer) StartOpen(ctx context.Context, _ int) (context.Context, func(error, bool)) {
+	return ctx, func(error, bool) {}
+}
@@ -0,0 +1,90 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type testSpanKey struct{}
+
+type testTracer struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (tr *testTracer) record(format string, args ...any) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	tr.events = append(tr.events, fmt.Sprintf(format, args...))
+}
+
+func (tr *testTracer) StartConnect(ctx context.Context, info ConnectInfo) (context.Context, func(error)) {
+	tr.record("start connect %s %s:%d/%s", info.Driver, info.Host, info.Port, info.DB)
+
+	return context.WithValue(ctx, testSpanKey{}, "connect"), func(err error) {
+		tr.record("end connect %v", err)
+	}
+}
+
+func (tr *testTracer) StartOpen(ctx context.Context, attempt int) (context.Context, func(error, bool)) {
+	tr.record("start open %d under %v", attempt, ctx.Value(testSpanKey{}))
+
+	return ctx, func(err error, authFailure bool) {
+		tr.record("end open %d %t", attempt, err != nil && authFailure)
+	}
+}
+
+func TestConnectorTracesConnect(t *testing.T) {
+	tr := &testTracer{}
+
+	c := newObservedConnector(t, 1, &Config{
+		Host:    host,
+		Port:    port,
+		DB:      "test",
+		Retries: 1,
+		Tracer:  tr,
+	})
+
+	var storeSpans []any
+
+	c.store = &testStore{
+		Getter: func(ctx context.Context) (Credentials, error) {
+			storeSpans = append(storeSpans, ctx.Value(testSpanKey{}))
+
+			return &testCredential{Username: username, Password: password}, nil
+		},
+		Refresher: func(ctx context.Context) (Credentials, error) {
+			storeSpans = append(storeSpans, ctx.Value(testSpanKey{}))
+
+			return &testCredential{Username: username, Password: password}, nil
+		},
+	}
+
+	if _, err := c.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		fmt.Sprintf("start connect driver %s:%d/test", host, port),
+		"start open 0 under connect",
+		"end open 0 true",
+		"start open 1 under connect",
+		"end open 1 false",
+		"end connect <nil>",
+	}
+
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	if !reflect.DeepEqual(tr.events, expected) {
+		t.Fatalf("expected '%v' but got '%v' instead", expected, tr.events)
+	}
+
+	if expectedSpans := []any{"connect", "connect"}; !reflect.DeepEqual(storeSpans, expectedSpans) {
+		t.Fatalf("expected store calls to be made under '%v' but got '%v' instead", expectedSpans, storeSpans)
+	}
+}
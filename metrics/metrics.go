@@ -0,0 +1,117 @@
+// Package metrics records metrics for the operations of a driver.Connector and its Store through a
+// backend-agnostic Recorder. Adapters for Prometheus and OpenTelemetry are available as the
+// independent metrics/prometheus and metrics/otel modules.
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+)
+
+// Operation is a call the Connector makes to its Store.
+type Operation string
+
+const (
+	// OperationGet is the first call to Store.Get.
+	OperationGet Operation = "get"
+	// OperationRefresh is a call to Store.Refresh.
+	OperationRefresh Operation = "refresh"
+)
+
+// Recorder records metrics in a specific backend.
+type Recorder interface {
+	// RecordConnect records an opened connection.
+	RecordConnect(ctx context.Context)
+	// RecordCredentialAge records how long ago the credentials a connection was opened with were
+	// issued. It isn't called when the Observer doesn't know when they were issued.
+	RecordCredentialAge(ctx context.Context, age time.Duration)
+	// RecordAuthFailure records the database rejecting credentials.
+	RecordAuthFailure(ctx context.Context)
+	// RecordStoreCall records a call to the Store, whether it failed and how long it took.
+	RecordStoreCall(ctx context.Context, op Operation, err error, d time.Duration)
+	// RecordRetriesExhausted records the Connector giving up on connecting after retrying.
+	RecordRetriesExhausted(ctx context.Context)
+}
+
+// Observer is a driver.Observer that records metrics with a Recorder.
+//
+// An Observer remembers when the Connector received each generation of credentials to work out their
+// age so each Connector needs its own Observer. Several Observers can share a Recorder.
+type Observer struct {
+	driver.NopObserver
+	recorder Recorder
+	now      func() time.Time
+	// mu guards issued, which records when the most recent generations of credentials were issued.
+	mu     sync.Mutex
+	issued map[uint64]time.Time
+}
+
+// issuedHistory is how many generations of credentials an Observer remembers. Connections are
+// almost always opened with the latest credentials so only a few are needed.
+const issuedHistory = 4
+
+var _ driver.Observer = (*Observer)(nil)
+
+// NewObserver creates an Observer that records metrics with r.
+func NewObserver(r Recorder) *Observer {
+	return &Observer{
+		recorder: r,
+		now:      time.Now,
+		issued:   make(map[uint64]time.Time, issuedHistory),
+	}
+}
+
+// OnGet implements the driver.Observer interface.
+func (o *Observer) OnGet(ctx context.Context, err error, d time.Duration) {
+	o.storeCall(ctx, OperationGet, err, d)
+}
+
+// OnRefreshDone implements the driver.Observer interface.
+func (o *Observer) OnRefreshDone(ctx context.Context, err error, d time.Duration) {
+	o.storeCall(ctx, OperationRefresh, err, d)
+}
+
+// OnAuthFailure implements the driver.Observer interface.
+func (o *Observer) OnAuthFailure(ctx context.Context, _ error, _ int) {
+	o.recorder.RecordAuthFailure(ctx)
+}
+
+// OnRetriesExhausted implements the driver.Observer interface.
+func (o *Observer) OnRetriesExhausted(ctx context.Context, _ error, _ int) {
+	o.recorder.RecordRetriesExhausted(ctx)
+}
+
+// OnNewCredentials implements the driver.Observer interface.
+func (o *Observer) OnNewCredentials(_ context.Context, generation uint64, issued time.Time) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.issued[generation] = issued
+
+	for gen := range o.issued {
+		if gen+issuedHistory <= generation {
+			delete(o.issued, gen)
+		}
+	}
+}
+
+// OnConnect implements the driver.Observer interface. The age of the credentials is only recorded
+// when the Observer knows when they were issued.
+func (o *Observer) OnConnect(ctx context.Context, generation uint64) {
+	o.mu.Lock()
+	issued, ok := o.issued[generation]
+	o.mu.Unlock()
+
+	o.recorder.RecordConnect(ctx)
+
+	if ok {
+		o.recorder.RecordCredentialAge(ctx, o.now().Sub(issued))
+	}
+}
+
+func (o *Observer) storeCall(ctx context.Context, op Operation, err error, d time.Duration) {
+	o.recorder.RecordStoreCall(ctx, op, err, d)
+}
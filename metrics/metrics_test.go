@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testRecorder struct {
+	records []string
+}
+
+func (r *testRecorder) RecordConnect(_ context.Context) {
+	r.records = append(r.records, "connect")
+}
+
+func (r *testRecorder) RecordCredentialAge(_ context.Context, age time.Duration) {
+	r.records = append(r.records, fmt.Sprintf("age %s", age))
+}
+
+func (r *testRecorder) RecordAuthFailure(_ context.Context) {
+	r.records = append(r.records, "auth failure")
+}
+
+func (r *testRecorder) RecordStoreCall(_ context.Context, op Operation, err error, d time.Duration) {
+	r.records = append(r.records, fmt.Sprintf("%s %v %s", op, err, d))
+}
+
+func (r *testRecorder) RecordRetriesExhausted(_ context.Context) {
+	r.records = append(r.records, "retries exhausted")
+}
+
+func TestObserverRecordsMetrics(t *testing.T) {
+	r := &testRecorder{}
+	o := NewObserver(r)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	o.now = func() time.Time {
+		return now
+	}
+
+	ctx := context.Background()
+	errRefresh := errors.New("refresh failed")
+
+	o.OnGet(ctx, nil, time.Second)
+	o.OnNewCredentials(ctx, 1, now)
+	now = now.Add(time.Minute)
+	o.OnConnect(ctx, 1)
+	o.OnAuthFailure(ctx, nil, 0)
+	o.OnRefreshStart(ctx)
+	o.OnRefreshDone(ctx, errRefresh, 2*time.Second)
+	o.OnRefreshDone(ctx, nil, 3*time.Second)
+	o.OnNewCredentials(ctx, 2, now)
+	now = now.Add(time.Minute)
+	o.OnConnect(ctx, 2)
+	o.OnConnect(ctx, 1)
+	// The age of credentials the Observer wasn't told about isn't recorded.
+	o.OnConnect(ctx, 3)
+	o.OnRetriesExhausted(ctx, nil, 1)
+
+	expected := []string{
+		"get <nil> 1s",
+		"connect",
+		"age 1m0s",
+		"auth failure",
+		"refresh refresh failed 2s",
+		"refresh <nil> 3s",
+		"connect",
+		"age 1m0s",
+		"connect",
+		"age 2m0s",
+		"connect",
+		"retries exhausted",
+	}
+
+	if !reflect.DeepEqual(r.records, expected) {
+		t.Fatalf("expected '%v' but got '%v' instead", expected, r.records)
+	}
+}
+
+func TestObserverForgetsOldGenerations(t *testing.T) {
+	r := &testRecorder{}
+	o := NewObserver(r)
+
+	ctx := context.Background()
+
+	for gen := range uint64(issuedHistory * 2) {
+		o.OnNewCredentials(ctx, gen+1, time.Now())
+	}
+
+	if len(o.issued) != issuedHistory {
+		t.Fatalf("expected '%d' but got '%d' instead", issuedHistory, len(o.issued))
+	}
+
+	r.records = nil
+	o.OnConnect(ctx, 1)
+
+	expected := []string{"connect"}
+	if !reflect.DeepEqual(r.records, expected) {
+		t.Fatalf("expected '%v' but got '%v' instead", expected, r.records)
+	}
+}
@@ -0,0 +1,148 @@
+// Package otel records go-db-credential-refresh metrics with OpenTelemetry.
+package otel
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/davepgreene/go-db-credential-refresh/metrics"
+)
+
+// ScopeName is the instrumentation scope of the Meter used by a Recorder.
+const ScopeName = "github.com/davepgreene/go-db-credential-refresh/metrics/otel"
+
+// Config configures a Recorder.
+type Config struct {
+	// MeterProvider provides the Meter the instruments are created with. It defaults to the global
+	// MeterProvider.
+	MeterProvider metric.MeterProvider
+	// Attributes are added to every measurement, which is useful to tell several Connectors apart.
+	Attributes []attribute.KeyValue
+}
+
+// Recorder implements the metrics.Recorder interface with OpenTelemetry instruments.
+type Recorder struct {
+	attributes       []attribute.KeyValue
+	attrs            metric.MeasurementOption
+	connects         metric.Int64Counter
+	authFailures     metric.Int64Counter
+	retriesExhausted metric.Int64Counter
+	storeCalls       metric.Int64Counter
+	storeDuration    metric.Float64Histogram
+	credentialAge    metric.Float64Histogram
+}
+
+var _ metrics.Recorder = (*Recorder)(nil)
+
+// NewRecorder creates a Recorder and its instruments.
+func NewRecorder(cfg *Config) (*Recorder, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	mp := cfg.MeterProvider
+	if mp == nil {
+		mp = otel.GetMeterProvider()
+	}
+
+	meter := mp.Meter(ScopeName)
+
+	r := &Recorder{
+		attributes: cfg.Attributes,
+		attrs:      metric.WithAttributes(cfg.Attributes...),
+	}
+
+	var err error
+
+	if r.connects, err = meter.Int64Counter(
+		"db.credentials.connects",
+		metric.WithDescription("Connections opened by the Connector."),
+		metric.WithUnit("{connection}"),
+	); err != nil {
+		return nil, err
+	}
+
+	if r.authFailures, err = meter.Int64Counter(
+		"db.credentials.auth_failures",
+		metric.WithDescription("Connection attempts rejected by the database because of the credentials."),
+		metric.WithUnit("{attempt}"),
+	); err != nil {
+		return nil, err
+	}
+
+	if r.retriesExhausted, err = meter.Int64Counter(
+		"db.credentials.retries_exhausted",
+		metric.WithDescription("Connections the Connector gave up on after retrying."),
+		metric.WithUnit("{connection}"),
+	); err != nil {
+		return nil, err
+	}
+
+	if r.storeCalls, err = meter.Int64Counter(
+		"db.credentials.store.calls",
+		metric.WithDescription("Calls to the credential Store by operation and result."),
+		metric.WithUnit("{call}"),
+	); err != nil {
+		return nil, err
+	}
+
+	if r.storeDuration, err = meter.Float64Histogram(
+		"db.credentials.store.duration",
+		metric.WithDescription("Latency of calls to the credential Store by operation."),
+		metric.WithUnit("s"),
+	); err != nil {
+		return nil, err
+	}
+
+	if r.credentialAge, err = meter.Float64Histogram(
+		"db.credentials.age",
+		metric.WithDescription("Age of the credentials used to open each connection."),
+		metric.WithUnit("s"),
+	); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// RecordConnect implements the metrics.Recorder interface.
+func (r *Recorder) RecordConnect(ctx context.Context) {
+	r.connects.Add(ctx, 1, r.attrs)
+}
+
+// RecordCredentialAge implements the metrics.Recorder interface.
+func (r *Recorder) RecordCredentialAge(ctx context.Context, age time.Duration) {
+	r.credentialAge.Record(ctx, age.Seconds(), r.attrs)
+}
+
+// RecordAuthFailure implements the metrics.Recorder interface.
+func (r *Recorder) RecordAuthFailure(ctx context.Context) {
+	r.authFailures.Add(ctx, 1, r.attrs)
+}
+
+// RecordStoreCall implements the metrics.Recorder interface.
+func (r *Recorder) RecordStoreCall(ctx context.Context, op metrics.Operation, err error, d time.Duration) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+
+	opAttr := attribute.String("operation", string(op))
+
+	r.storeCalls.Add(ctx, 1, metric.WithAttributes(r.with(opAttr, attribute.String("result", result))...))
+	r.storeDuration.Record(ctx, d.Seconds(), metric.WithAttributes(r.with(opAttr)...))
+}
+
+// RecordRetriesExhausted implements the metrics.Recorder interface.
+func (r *Recorder) RecordRetriesExhausted(ctx context.Context) {
+	r.retriesExhausted.Add(ctx, 1, r.attrs)
+}
+
+// with returns the configured attributes followed by attrs.
+func (r *Recorder) with(attrs ...attribute.KeyValue) []attribute.KeyValue {
+	return append(append(make([]attribute.KeyValue, 0, len(r.attributes)+len(attrs)), r.attributes...), attrs...)
+}
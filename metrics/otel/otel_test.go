@@ -0,0 +1,95 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/davepgreene/go-db-credential-refresh/metrics"
+)
+
+func TestRecorderRecordsMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	r, err := NewRecorder(&Config{
+		MeterProvider: mp,
+		Attributes:    []attribute.KeyValue{attribute.String("connector", "test")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	r.RecordStoreCall(ctx, metrics.OperationGet, nil, time.Second)
+	r.RecordStoreCall(ctx, metrics.OperationRefresh, errors.New("vault is sealed"), time.Second)
+	r.RecordStoreCall(ctx, metrics.OperationRefresh, errors.New("vault is sealed"), time.Second)
+	r.RecordAuthFailure(ctx)
+	r.RecordRetriesExhausted(ctx)
+	r.RecordConnect(ctx)
+	r.RecordCredentialAge(ctx, 2*time.Minute)
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatal(err)
+	}
+
+	sums := map[string]int64{}
+	histograms := map[string]uint64{}
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, dp := range data.DataPoints {
+					if v, ok := dp.Attributes.Value("connector"); !ok || v.AsString() != "test" {
+						t.Fatalf("expected '%s' to have a connector attribute", m.Name)
+					}
+
+					key := m.Name
+					if result, ok := dp.Attributes.Value("result"); ok {
+						op, _ := dp.Attributes.Value("operation")
+						key += "/" + op.AsString() + "/" + result.AsString()
+					}
+
+					sums[key] += dp.Value
+				}
+			case metricdata.Histogram[float64]:
+				for _, dp := range data.DataPoints {
+					histograms[m.Name] += dp.Count
+				}
+			}
+		}
+	}
+
+	expectedSums := map[string]int64{
+		"db.credentials.connects":                  1,
+		"db.credentials.auth_failures":             1,
+		"db.credentials.retries_exhausted":         1,
+		"db.credentials.store.calls/get/success":   1,
+		"db.credentials.store.calls/refresh/error": 2,
+	}
+
+	for name, expected := range expectedSums {
+		if sums[name] != expected {
+			t.Fatalf("expected '%s' to be '%d' but got '%d' instead", name, expected, sums[name])
+		}
+	}
+
+	expectedHistograms := map[string]uint64{
+		"db.credentials.store.duration": 3,
+		"db.credentials.age":            1,
+	}
+
+	for name, expected := range expectedHistograms {
+		if histograms[name] != expected {
+			t.Fatalf("expected '%s' to have '%d' records but got '%d' instead", name, expected, histograms[name])
+		}
+	}
+}
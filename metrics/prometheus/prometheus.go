@@ -0,0 +1,159 @@
+// Package prometheus records go-db-credential-refresh metrics with the Prometheus client library.
+package prometheus
+
+import (
+	"context"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+
+	"github.com/davepgreene/go-db-credential-refresh/metrics"
+)
+
+// DefaultNamespace is the default value of Config.Namespace.
+const DefaultNamespace = "db_credential_refresh"
+
+// Config configures a Recorder.
+type Config struct {
+	// Registerer is where the metrics are registered. It defaults to prometheus.DefaultRegisterer.
+	Registerer prom.Registerer
+	// Namespace prefixes the name of every metric. It defaults to DefaultNamespace.
+	Namespace string
+	// ConstLabels are added to every metric, which is useful to tell several Connectors apart.
+	ConstLabels prom.Labels
+	// Buckets are the histogram buckets, in seconds, for Store call latency. They default to
+	// prometheus.DefBuckets.
+	Buckets []float64
+	// AgeBuckets are the histogram buckets, in seconds, for the age of credentials when a
+	// connection is opened. They default to buckets between one minute and one day.
+	AgeBuckets []float64
+}
+
+// Recorder implements the metrics.Recorder interface with Prometheus metrics.
+type Recorder struct {
+	connects         prom.Counter
+	authFailures     prom.Counter
+	retriesExhausted prom.Counter
+	storeCalls       *prom.CounterVec
+	storeDuration    *prom.HistogramVec
+	credentialAge    prom.Histogram
+}
+
+var _ metrics.Recorder = (*Recorder)(nil)
+
+//nolint:gochecknoglobals
+var defaultAgeBuckets = []float64{60, 300, 900, 1800, 3600, 7200, 14400, 28800, 86400}
+
+// NewRecorder creates a Recorder and registers its metrics.
+func NewRecorder(cfg *Config) (*Recorder, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	reg := cfg.Registerer
+	if reg == nil {
+		reg = prom.DefaultRegisterer
+	}
+
+	namespace := cfg.Namespace
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+
+	buckets := cfg.Buckets
+	if len(buckets) == 0 {
+		buckets = prom.DefBuckets
+	}
+
+	ageBuckets := cfg.AgeBuckets
+	if len(ageBuckets) == 0 {
+		ageBuckets = defaultAgeBuckets
+	}
+
+	r := &Recorder{
+		connects: prom.NewCounter(prom.CounterOpts{
+			Namespace:   namespace,
+			Name:        "connects_total",
+			Help:        "Connections opened by the Connector.",
+			ConstLabels: cfg.ConstLabels,
+		}),
+		authFailures: prom.NewCounter(prom.CounterOpts{
+			Namespace:   namespace,
+			Name:        "auth_failures_total",
+			Help:        "Connection attempts rejected by the database because of the credentials.",
+			ConstLabels: cfg.ConstLabels,
+		}),
+		retriesExhausted: prom.NewCounter(prom.CounterOpts{
+			Namespace:   namespace,
+			Name:        "retries_exhausted_total",
+			Help:        "Connections the Connector gave up on after retrying.",
+			ConstLabels: cfg.ConstLabels,
+		}),
+		storeCalls: prom.NewCounterVec(prom.CounterOpts{
+			Namespace:   namespace,
+			Name:        "store_calls_total",
+			Help:        "Calls to the credential Store by operation and result.",
+			ConstLabels: cfg.ConstLabels,
+		}, []string{"operation", "result"}),
+		storeDuration: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace:   namespace,
+			Name:        "store_call_duration_seconds",
+			Help:        "Latency of calls to the credential Store by operation.",
+			ConstLabels: cfg.ConstLabels,
+			Buckets:     buckets,
+		}, []string{"operation"}),
+		credentialAge: prom.NewHistogram(prom.HistogramOpts{
+			Namespace:   namespace,
+			Name:        "credential_age_seconds",
+			Help:        "Age of the credentials used to open each connection.",
+			ConstLabels: cfg.ConstLabels,
+			Buckets:     ageBuckets,
+		}),
+	}
+
+	for _, c := range []prom.Collector{
+		r.connects,
+		r.authFailures,
+		r.retriesExhausted,
+		r.storeCalls,
+		r.storeDuration,
+		r.credentialAge,
+	} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// RecordConnect implements the metrics.Recorder interface.
+func (r *Recorder) RecordConnect(_ context.Context) {
+	r.connects.Inc()
+}
+
+// RecordCredentialAge implements the metrics.Recorder interface.
+func (r *Recorder) RecordCredentialAge(_ context.Context, age time.Duration) {
+	r.credentialAge.Observe(age.Seconds())
+}
+
+// RecordAuthFailure implements the metrics.Recorder interface.
+func (r *Recorder) RecordAuthFailure(_ context.Context) {
+	r.authFailures.Inc()
+}
+
+// RecordStoreCall implements the metrics.Recorder interface.
+func (r *Recorder) RecordStoreCall(_ context.Context, op metrics.Operation, err error, d time.Duration) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+
+	r.storeCalls.WithLabelValues(string(op), result).Inc()
+	r.storeDuration.WithLabelValues(string(op)).Observe(d.Seconds())
+}
+
+// RecordRetriesExhausted implements the metrics.Recorder interface.
+func (r *Recorder) RecordRetriesExhausted(_ context.Context) {
+	r.retriesExhausted.Inc()
+}
@@ -0,0 +1,85 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/davepgreene/go-db-credential-refresh/metrics"
+)
+
+func TestRecorderRecordsMetrics(t *testing.T) {
+	reg := prom.NewPedanticRegistry()
+
+	r, err := NewRecorder(&Config{
+		Registerer:  reg,
+		ConstLabels: prom.Labels{"connector": "test"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	r.RecordStoreCall(ctx, metrics.OperationGet, nil, time.Second)
+	r.RecordStoreCall(ctx, metrics.OperationRefresh, errors.New("vault is sealed"), time.Second)
+	r.RecordStoreCall(ctx, metrics.OperationRefresh, errors.New("vault is sealed"), time.Second)
+	r.RecordAuthFailure(ctx)
+	r.RecordRetriesExhausted(ctx)
+	r.RecordConnect(ctx)
+	r.RecordCredentialAge(ctx, 2*time.Minute)
+
+	expected := `
+# HELP db_credential_refresh_auth_failures_total Connection attempts rejected by the database because of the credentials.
+# TYPE db_credential_refresh_auth_failures_total counter
+db_credential_refresh_auth_failures_total{connector="test"} 1
+# HELP db_credential_refresh_connects_total Connections opened by the Connector.
+# TYPE db_credential_refresh_connects_total counter
+db_credential_refresh_connects_total{connector="test"} 1
+# HELP db_credential_refresh_retries_exhausted_total Connections the Connector gave up on after retrying.
+# TYPE db_credential_refresh_retries_exhausted_total counter
+db_credential_refresh_retries_exhausted_total{connector="test"} 1
+# HELP db_credential_refresh_store_calls_total Calls to the credential Store by operation and result.
+# TYPE db_credential_refresh_store_calls_total counter
+db_credential_refresh_store_calls_total{connector="test",operation="get",result="success"} 1
+db_credential_refresh_store_calls_total{connector="test",operation="refresh",result="error"} 2
+`
+
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"db_credential_refresh_auth_failures_total",
+		"db_credential_refresh_connects_total",
+		"db_credential_refresh_retries_exhausted_total",
+		"db_credential_refresh_store_calls_total",
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := testutil.GatherAndCount(reg,
+		"db_credential_refresh_store_call_duration_seconds",
+		"db_credential_refresh_credential_age_seconds",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 3 {
+		t.Fatalf("expected '%d' but got '%d' instead", 3, count)
+	}
+}
+
+func TestNewRecorderFailsToRegisterTwice(t *testing.T) {
+	reg := prom.NewRegistry()
+
+	if _, err := NewRecorder(&Config{Registerer: reg}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewRecorder(&Config{Registerer: reg}); err == nil {
+		t.Fatal("expected an error but got nil")
+	}
+}
@@ -0,0 +1,170 @@
+package awsrds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+)
+
+// ErrDBNotFound is returned by ResolveEndpoint when there is no cluster or instance with the
+// identifier it was given.
+var ErrDBNotFound = errors.New("no DB cluster or instance found")
+
+var errEndpointUnavailable = errors.New("endpoint isn't available yet")
+
+// InferRegion returns the region encoded in an RDS hostname like
+// mydb.123456789012.us-east-1.rds.amazonaws.com or mydb.123456789012.rds.cn-north-1.amazonaws.com.cn.
+// The endpoint can include a port and scheme. It returns false for hostnames that aren't RDS
+// endpoints, like custom DNS names pointing at a database.
+func InferRegion(endpoint string) (string, bool) {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", false
+	}
+
+	labels := strings.Split(strings.ToLower(u.Hostname()), ".")
+	for i, label := range labels {
+		if label != "rds" {
+			continue
+		}
+
+		// Most partitions put the region before the service and China puts it after.
+		if i > 0 && i+1 < len(labels) && labels[i+1] == "amazonaws" {
+			return labels[i-1], true
+		}
+
+		if i+2 < len(labels) && labels[i+2] == "amazonaws" {
+			return labels[i+1], true
+		}
+	}
+
+	return "", false
+}
+
+// EndpointKind selects which endpoint of an Aurora cluster ResolveEndpoint returns.
+type EndpointKind int
+
+const (
+	// WriterEndpoint is the cluster endpoint, which always points at the writer instance.
+	WriterEndpoint EndpointKind = iota
+	// ReaderEndpoint load balances connections across the reader instances of a cluster.
+	ReaderEndpoint
+)
+
+// Endpoint is the address of an RDS instance or cluster.
+type Endpoint struct {
+	Host   string
+	Port   int
+	Region string
+}
+
+// String returns the endpoint as host:port.
+func (e Endpoint) String() string {
+	return net.JoinHostPort(e.Host, strconv.Itoa(e.Port))
+}
+
+// Apply sets the endpoint of the store and the host and port of the database from e so they can't
+// drift apart. The region of the store is only set when it is empty. Either config can be nil.
+func (e Endpoint) Apply(storeCfg *Config, dbCfg *driver.Config) {
+	if storeCfg != nil {
+		storeCfg.Endpoint = e.String()
+		if storeCfg.Region == "" {
+			storeCfg.Region = e.Region
+		}
+	}
+
+	if dbCfg != nil {
+		dbCfg.Host = e.Host
+		dbCfg.Port = e.Port
+	}
+}
+
+// ResolveEndpoint looks up the endpoint of an Aurora or Multi-AZ DB cluster, or of a DB instance if
+// there's no cluster with that identifier, through the RDS API. kind selects the writer or reader
+// endpoint of a cluster. Instances only have one endpoint so kind is ignored for them.
+//
+// The region, credentials, HTTP client and base endpoint are taken from awsCfg. The caller needs the
+// rds:DescribeDBClusters and rds:DescribeDBInstances permissions.
+func ResolveEndpoint(ctx context.Context, awsCfg aws.Config, identifier string, kind EndpointKind) (Endpoint, error) {
+	if identifier == "" {
+		return Endpoint{}, &errMissingConfigItem{item: "identifier"}
+	}
+
+	if awsCfg.Region == "" {
+		return Endpoint{}, &errMissingConfigItem{item: "region"}
+	}
+
+	if awsCfg.Credentials == nil {
+		return Endpoint{}, errMissingCredentials
+	}
+
+	client := rds.NewFromConfig(awsCfg)
+
+	clusters, err := client.DescribeDBClusters(ctx, &rds.DescribeDBClustersInput{
+		DBClusterIdentifier: aws.String(identifier),
+	})
+
+	var clusterNotFound *types.DBClusterNotFoundFault
+
+	switch {
+	case err == nil && len(clusters.DBClusters) > 0:
+		c := clusters.DBClusters[0]
+
+		host := c.Endpoint
+		if kind == ReaderEndpoint {
+			host = c.ReaderEndpoint
+		}
+
+		return newEndpoint(identifier, aws.ToString(host), aws.ToInt32(c.Port), awsCfg.Region)
+	case err != nil && !errors.As(err, &clusterNotFound):
+		return Endpoint{}, err
+	}
+
+	instances, err := client.DescribeDBInstances(ctx, &rds.DescribeDBInstancesInput{
+		DBInstanceIdentifier: aws.String(identifier),
+	})
+
+	var instanceNotFound *types.DBInstanceNotFoundFault
+
+	switch {
+	case errors.As(err, &instanceNotFound):
+		return Endpoint{}, fmt.Errorf("%w: %s", ErrDBNotFound, identifier)
+	case err != nil:
+		return Endpoint{}, err
+	case len(instances.DBInstances) == 0:
+		return Endpoint{}, fmt.Errorf("%w: %s", ErrDBNotFound, identifier)
+	}
+
+	var (
+		host string
+		port int32
+	)
+
+	if e := instances.DBInstances[0].Endpoint; e != nil {
+		host, port = aws.ToString(e.Address), aws.ToInt32(e.Port)
+	}
+
+	return newEndpoint(identifier, host, port, awsCfg.Region)
+}
+
+func newEndpoint(identifier, host string, port int32, region string) (Endpoint, error) {
+	// Clusters and instances that are still being created don't have endpoints.
+	if host == "" || port == 0 {
+		return Endpoint{}, fmt.Errorf("%s: %w", identifier, errEndpointUnavailable)
+	}
+
+	return Endpoint{Host: host, Port: int(port), Region: region}, nil
+}
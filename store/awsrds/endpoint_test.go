@@ -0,0 +1,282 @@
+package awsrds
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/smithy-go"
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+)
+
+func TestInferRegion(t *testing.T) {
+	testCases := []struct {
+		description string
+		endpoint    string
+		region      string
+		ok          bool
+	}{
+		{
+			description: "instance",
+			endpoint:    "mydb.123456789012.us-east-1.rds.amazonaws.com:5432",
+			region:      "us-east-1",
+			ok:          true,
+		},
+		{
+			description: "cluster reader with scheme",
+			endpoint:    "https://mycluster.cluster-ro-abc123.eu-west-2.rds.amazonaws.com:3306",
+			region:      "eu-west-2",
+			ok:          true,
+		},
+		{
+			description: "china",
+			endpoint:    "mydb.abc123.rds.cn-north-1.amazonaws.com.cn:5432",
+			region:      "cn-north-1",
+			ok:          true,
+		},
+		{
+			description: "no port",
+			endpoint:    "MyDB.abc123.US-WEST-2.RDS.AMAZONAWS.COM",
+			region:      "us-west-2",
+			ok:          true,
+		},
+		{
+			description: "custom hostname",
+			endpoint:    "db.example.com:5432",
+		},
+		{
+			description: "rds label only",
+			endpoint:    "rds.example.com:5432",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			region, ok := InferRegion(tc.endpoint)
+			if ok != tc.ok {
+				t.Fatalf("expected '%v' but got '%v' instead", tc.ok, ok)
+			}
+
+			if region != tc.region {
+				t.Fatalf("expected '%s' but got '%s' instead", tc.region, region)
+			}
+		})
+	}
+}
+
+func TestNewStoreInfersRegion(t *testing.T) {
+	s, err := NewStore(&Config{
+		Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		Endpoint:    "mydb.123456789012.ap-southeast-2.rds.amazonaws.com:5432",
+		User:        "app",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Region != "ap-southeast-2" {
+		t.Fatalf("expected '%s' but got '%s' instead", "ap-southeast-2", s.Region)
+	}
+}
+
+const (
+	clusterResponse = `<DescribeDBClustersResponse xmlns="http://rds.amazonaws.com/doc/2014-10-31/">
+  <DescribeDBClustersResult>
+    <DBClusters>
+      <DBCluster>
+        <DBClusterIdentifier>mycluster</DBClusterIdentifier>
+        <Endpoint>mycluster.cluster-abc123.us-east-1.rds.amazonaws.com</Endpoint>
+        <ReaderEndpoint>mycluster.cluster-ro-abc123.us-east-1.rds.amazonaws.com</ReaderEndpoint>
+        <Port>5432</Port>
+      </DBCluster>
+    </DBClusters>
+  </DescribeDBClustersResult>
+</DescribeDBClustersResponse>`
+	instanceResponse = `<DescribeDBInstancesResponse xmlns="http://rds.amazonaws.com/doc/2014-10-31/">
+  <DescribeDBInstancesResult>
+    <DBInstances>
+      <DBInstance>
+        <DBInstanceIdentifier>mydb</DBInstanceIdentifier>
+        <Endpoint>
+          <Address>mydb.abc123.us-east-1.rds.amazonaws.com</Address>
+          <Port>3306</Port>
+        </Endpoint>
+      </DBInstance>
+    </DBInstances>
+  </DescribeDBInstancesResult>
+</DescribeDBInstancesResponse>`
+	creatingInstanceResponse = `<DescribeDBInstancesResponse xmlns="http://rds.amazonaws.com/doc/2014-10-31/">
+  <DescribeDBInstancesResult>
+    <DBInstances>
+      <DBInstance>
+        <DBInstanceIdentifier>creating</DBInstanceIdentifier>
+      </DBInstance>
+    </DBInstances>
+  </DescribeDBInstancesResult>
+</DescribeDBInstancesResponse>`
+	errorResponseFormat = `<ErrorResponse xmlns="http://rds.amazonaws.com/doc/2014-10-31/">
+  <Error>
+    <Type>Sender</Type>
+    <Code>%s</Code>
+    <Message>not allowed</Message>
+  </Error>
+  <RequestId>1234</RequestId>
+</ErrorResponse>`
+)
+
+// newFakeRDS starts a fake RDS API that knows the cluster "mycluster" and the instances "mydb" and
+// "creating", and denies access to anything called "forbidden".
+func newFakeRDS(t *testing.T) aws.Config {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Authorization"), "/us-east-1/rds/aws4_request") {
+			t.Errorf("expected a request signed for rds in us-east-1 but got '%s' instead", r.Header.Get("Authorization"))
+		}
+
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+
+		if r.Form.Get("Version") != "2014-10-31" {
+			t.Errorf("expected '%s' but got '%s' instead", "2014-10-31", r.Form.Get("Version"))
+		}
+
+		fault := func(status int, code string) {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(strings.Replace(errorResponseFormat, "%s", code, 1)))
+		}
+
+		switch action := r.Form.Get("Action"); {
+		case r.Form.Get("DBClusterIdentifier") == "forbidden":
+			fault(http.StatusForbidden, "AccessDenied")
+		case action == "DescribeDBClusters" && r.Form.Get("DBClusterIdentifier") == "mycluster":
+			_, _ = w.Write([]byte(clusterResponse))
+		case action == "DescribeDBClusters":
+			fault(http.StatusNotFound, "DBClusterNotFoundFault")
+		case action == "DescribeDBInstances" && r.Form.Get("DBInstanceIdentifier") == "mydb":
+			_, _ = w.Write([]byte(instanceResponse))
+		case action == "DescribeDBInstances" && r.Form.Get("DBInstanceIdentifier") == "creating":
+			_, _ = w.Write([]byte(creatingInstanceResponse))
+		default:
+			fault(http.StatusNotFound, "DBInstanceNotFound")
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return aws.Config{
+		Region:       "us-east-1",
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		BaseEndpoint: aws.String(srv.URL),
+		HTTPClient:   srv.Client(),
+	}
+}
+
+func TestResolveEndpoint(t *testing.T) {
+	awsCfg := newFakeRDS(t)
+
+	testCases := []struct {
+		description string
+		identifier  string
+		kind        EndpointKind
+		expected    string
+	}{
+		{
+			description: "cluster writer",
+			identifier:  "mycluster",
+			kind:        WriterEndpoint,
+			expected:    "mycluster.cluster-abc123.us-east-1.rds.amazonaws.com:5432",
+		},
+		{
+			description: "cluster reader",
+			identifier:  "mycluster",
+			kind:        ReaderEndpoint,
+			expected:    "mycluster.cluster-ro-abc123.us-east-1.rds.amazonaws.com:5432",
+		},
+		{
+			description: "instance",
+			identifier:  "mydb",
+			kind:        ReaderEndpoint,
+			expected:    "mydb.abc123.us-east-1.rds.amazonaws.com:3306",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			e, err := ResolveEndpoint(context.Background(), awsCfg, tc.identifier, tc.kind)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if e.String() != tc.expected {
+				t.Fatalf("expected '%s' but got '%s' instead", tc.expected, e.String())
+			}
+
+			if e.Region != "us-east-1" {
+				t.Fatalf("expected '%s' but got '%s' instead", "us-east-1", e.Region)
+			}
+		})
+	}
+}
+
+func TestResolveEndpointErrors(t *testing.T) {
+	awsCfg := newFakeRDS(t)
+
+	if _, err := ResolveEndpoint(context.Background(), awsCfg, "missing", WriterEndpoint); !errors.Is(err, ErrDBNotFound) {
+		t.Fatalf("expected '%v' but got '%v' instead", ErrDBNotFound, err)
+	}
+
+	if _, err := ResolveEndpoint(context.Background(), awsCfg, "creating", WriterEndpoint); !errors.Is(
+		err, errEndpointUnavailable,
+	) {
+		t.Fatalf("expected '%v' but got '%v' instead", errEndpointUnavailable, err)
+	}
+
+	_, err := ResolveEndpoint(context.Background(), awsCfg, "forbidden", WriterEndpoint)
+
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "AccessDenied" {
+		t.Fatalf("expected an AccessDenied error but got '%v' instead", err)
+	}
+
+	awsCfg.Region = ""
+	if _, err := ResolveEndpoint(context.Background(), awsCfg, "mydb", WriterEndpoint); err == nil {
+		t.Fatal("expected an error but didn't get one")
+	}
+}
+
+func TestEndpointApply(t *testing.T) {
+	e := Endpoint{Host: "mydb.abc123.us-east-1.rds.amazonaws.com", Port: 5432, Region: "us-east-1"}
+
+	storeCfg := &Config{
+		Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		User:        "app",
+	}
+	dbCfg := &driver.Config{DB: "app"}
+
+	e.Apply(storeCfg, dbCfg)
+
+	if storeCfg.Endpoint != e.String() {
+		t.Fatalf("expected '%s' but got '%s' instead", e.String(), storeCfg.Endpoint)
+	}
+
+	if storeCfg.Region != e.Region {
+		t.Fatalf("expected '%s' but got '%s' instead", e.Region, storeCfg.Region)
+	}
+
+	if dbCfg.Host != e.Host || dbCfg.Port != e.Port {
+		t.Fatalf("expected '%s' but got '%s:%d' instead", e.String(), dbCfg.Host, dbCfg.Port)
+	}
+
+	if _, err := NewStore(storeCfg); err != nil {
+		t.Fatal(err)
+	}
+
+	// A nil config is skipped.
+	e.Apply(nil, dbCfg)
+}
@@ -0,0 +1,100 @@
+package awsrds
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+	"github.com/davepgreene/go-db-credential-refresh/store"
+)
+
+// StoreName is the name the RDS store is registered with in the store package.
+const StoreName = "awsrds"
+
+func init() { //nolint:gochecknoinits
+	if err := store.Register(StoreName, NewStoreFromURL); err != nil {
+		panic(err)
+	}
+}
+
+// NewStoreFromURL creates an RDS store from a URL like
+//
+//	awsrds://mydb.123456789012.us-east-1.rds.amazonaws.com:5432?user=app
+//
+// It is registered with the store package so importing this package makes awsrds:// URIs available
+// to store.Open. The host of the URL is the database endpoint. AWS credentials come from the default
+// credential chain. The query accepts:
+//
+//   - endpoint: the database endpoint as host:port when the URL has no host.
+//   - user: the database user to authenticate as.
+//   - region: the region of the database. It defaults to the region in the endpoint's hostname and
+//     then to the region of the AWS configuration.
+//   - profile: the shared configuration profile to load credentials from.
+//   - refresh_margin: how long before a token expires that a new one is built, e.g. 2m.
+func NewStoreFromURL(ctx context.Context, u *url.URL) (driver.Store, error) {
+	q := u.Query()
+
+	if err := store.CheckParams(StoreName, q, "endpoint", "user", "region", "profile",
+		"refresh_margin"); err != nil {
+		return nil, err
+	}
+
+	endpoint := u.Host
+	if endpoint == "" {
+		endpoint = q.Get("endpoint")
+	}
+
+	if endpoint == "" {
+		return nil, paramError("endpoint", store.ErrMissingParam)
+	}
+
+	user := q.Get("user")
+	if user == "" {
+		return nil, paramError("user", store.ErrMissingParam)
+	}
+
+	var margin time.Duration
+	if v := q.Get("refresh_margin"); v != "" {
+		var err error
+		if margin, err = time.ParseDuration(v); err != nil {
+			return nil, paramError("refresh_margin", err)
+		}
+	}
+
+	region := q.Get("region")
+	if region == "" {
+		region, _ = InferRegion(endpoint)
+	}
+
+	var opts []func(*config.LoadOptions) error
+	if region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+
+	if profile := q.Get("profile"); profile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(profile))
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if awsCfg.Region == "" {
+		return nil, paramError("region", store.ErrMissingParam)
+	}
+
+	return NewStore(&Config{
+		Credentials:   awsCfg.Credentials,
+		Endpoint:      endpoint,
+		Region:        awsCfg.Region,
+		User:          user,
+		RefreshMargin: margin,
+	})
+}
+
+func paramError(param string, err error) error {
+	return &store.ParamError{Store: StoreName, Param: param, Err: err}
+}
@@ -0,0 +1,119 @@
+package awsrds
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+	"github.com/davepgreene/go-db-credential-refresh/store"
+)
+
+// isolateAWSConfig stops tests from picking up the AWS configuration of the machine they run on.
+func isolateAWSConfig(t *testing.T) {
+	t.Helper()
+
+	t.Setenv("AWS_CONFIG_FILE", t.TempDir()+"/config")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", t.TempDir()+"/credentials")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "SECRET")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	t.Setenv("AWS_PROFILE", "")
+}
+
+func TestStoreIsRegistered(t *testing.T) {
+	isolateAWSConfig(t)
+
+	ctx := context.Background()
+
+	fromURI, err := store.Open(ctx,
+		"awsrds://mydb.123456789012.us-east-1.rds.amazonaws.com:5432?region=us-east-1&user=app&refresh_margin=2m")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromMap, err := store.OpenMap(ctx, StoreName, map[string]string{
+		"endpoint": "mydb.123456789012.us-east-1.rds.amazonaws.com:5432",
+		"user":     "app",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []driver.Store{fromURI, fromMap} {
+		creds, err := s.Get(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if creds.GetUsername() != "app" {
+			t.Fatalf("expected '%s' but got '%s' instead", "app", creds.GetUsername())
+		}
+
+		if !strings.Contains(creds.GetPassword(), "X-Amz-Signature=") {
+			t.Fatalf("expected a signed token but got '%s' instead", creds.GetPassword())
+		}
+	}
+}
+
+func TestNewStoreFromURLReportsMissingParams(t *testing.T) {
+	isolateAWSConfig(t)
+
+	testCases := []struct {
+		description string
+		uri         string
+		param       string
+		err         error
+	}{
+		{
+			description: "missing endpoint",
+			uri:         "awsrds://?region=us-east-1&user=app",
+			param:       "endpoint",
+			err:         store.ErrMissingParam,
+		},
+		{
+			description: "missing user",
+			uri:         "awsrds://mydb.example.com:5432?region=us-east-1",
+			param:       "user",
+			err:         store.ErrMissingParam,
+		},
+		{
+			description: "missing region",
+			uri:         "awsrds://mydb.example.com:5432?user=app",
+			param:       "region",
+			err:         store.ErrMissingParam,
+		},
+		{
+			description: "invalid refresh margin",
+			uri:         "awsrds://mydb.example.com:5432?region=us-east-1&user=app&refresh_margin=soon",
+			param:       "refresh_margin",
+		},
+		{
+			description: "unknown parameter",
+			uri:         "awsrds://mydb.example.com:5432?region=us-east-1&user=app&usr=app",
+			param:       "usr",
+			err:         store.ErrUnknownParam,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			_, err := store.Open(context.Background(), tc.uri)
+
+			var paramErr *store.ParamError
+			if !errors.As(err, &paramErr) {
+				t.Fatalf("expected '%T' but got '%v' instead", paramErr, err)
+			}
+
+			if paramErr.Param != tc.param {
+				t.Fatalf("expected '%s' but got '%s' instead", tc.param, paramErr.Param)
+			}
+
+			if tc.err != nil && !errors.Is(err, tc.err) {
+				t.Fatalf("expected '%v' but got '%v' instead", tc.err, err)
+			}
+		})
+	}
+}
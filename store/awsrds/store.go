@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/rds/auth"
@@ -13,10 +16,18 @@ import (
 	"github.com/davepgreene/go-db-credential-refresh/store"
 )
 
+// tokenLifetime is how long an RDS IAM authentication token is valid for.
+// See https://docs.aws.amazon.com/AmazonRDS/latest/UserGuide/UsingWithRDS.IAMDBAuth.Connecting.html
+const tokenLifetime = 15 * time.Minute
+
+// DefaultRefreshMargin is the default value of Config.RefreshMargin.
+const DefaultRefreshMargin = time.Minute
+
 var (
 	errMissingConfig      = errors.New("config is required")
 	errMalformedEndpoint  = errors.New("endpoint must be in the form of 'hostname:port'")
 	errMissingCredentials = errors.New("credentials cannot be nil")
+	errInvalidMargin      = fmt.Errorf("refresh margin must be shorter than the token lifetime of %s", tokenLifetime)
 )
 
 type errMissingConfigItem struct {
@@ -31,15 +42,27 @@ func (e errMissingConfigItem) Error() string {
 // https://aws.amazon.com/premiumsupport/knowledge-center/users-connect-rds-iam/
 type Store struct {
 	*Config
-	creds driver.Credentials
+	// now is the clock tokens are timed with. Tests replace it.
+	now func() time.Time
+
+	mu     sync.Mutex
+	creds  *store.Credential
+	issued time.Time
 }
 
 // Config contains configuration information.
 type Config struct {
 	Credentials aws.CredentialsProvider
 	Endpoint    string // Endpoint takes the form of host:port
-	Region      string
-	User        string
+	// Region is the region of the database. It is inferred from RDS hostnames like
+	// mydb.123456789012.us-east-1.rds.amazonaws.com when it isn't set.
+	Region string
+	User   string
+	// RefreshMargin is how long before a token expires that Get builds a new one, so a connection
+	// is never opened with a token that is about to expire. It defaults to DefaultRefreshMargin.
+	RefreshMargin time.Duration
+	// Logger logs when IAM authentication tokens are built. Tokens are never logged. It is optional.
+	Logger *slog.Logger
 }
 
 // NewStore creates a new RDS-backed store.
@@ -53,7 +76,12 @@ func NewStore(c *Config) (*Store, error) {
 	}
 
 	if c.Region == "" {
-		return nil, &errMissingConfigItem{item: "region"}
+		region, ok := InferRegion(c.Endpoint)
+		if !ok {
+			return nil, &errMissingConfigItem{item: "region"}
+		}
+
+		c.Region = region
 	}
 
 	if c.User == "" {
@@ -81,34 +109,73 @@ func NewStore(c *Config) (*Store, error) {
 		return nil, errMissingCredentials
 	}
 
+	if c.RefreshMargin <= 0 {
+		c.RefreshMargin = DefaultRefreshMargin
+	}
+
+	if c.RefreshMargin >= tokenLifetime {
+		return nil, errInvalidMargin
+	}
+
 	return &Store{
 		Config: c,
+		now:    time.Now,
 	}, nil
 }
 
-// Get implements the Store interface.
+// Get implements the Store interface. The cached token is returned until it is within RefreshMargin
+// of expiring, when a new one is built.
 func (v *Store) Get(ctx context.Context) (driver.Credentials, error) {
-	if v.creds != nil {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.creds != nil && v.now().Before(v.creds.Expiration.Add(-v.RefreshMargin)) {
 		return v.creds, nil
 	}
 
-	return v.Refresh(ctx)
+	return v.build(ctx)
 }
 
 // Refresh implements the store interface.
 func (v *Store) Refresh(ctx context.Context) (driver.Credentials, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.build(ctx)
+}
+
+// IssuedAt returns when the cached token was built, or a zero time if none has been.
+func (v *Store) IssuedAt() time.Time {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.issued
+}
+
+// build builds a new token and caches it. It must be called with v.mu held.
+func (v *Store) build(ctx context.Context) (*store.Credential, error) {
+	issued := v.now()
+
 	token, err := auth.BuildAuthToken(ctx, v.Endpoint, v.Region, v.User, v.Credentials)
 	if err != nil {
 		return nil, err
 	}
 
 	creds := &store.Credential{
-		Username: v.User,
-		Password: token,
+		Username:   v.User,
+		Password:   token,
+		Expiration: issued.Add(tokenLifetime),
+		Token:      true,
+	}
+
+	if v.Logger != nil {
+		v.Logger.DebugContext(ctx, "built RDS IAM authentication token",
+			"endpoint", v.Endpoint, "region", v.Region, "user", v.User, "expiration", creds.Expiration)
 	}
 
 	// Cache the credentials
 	v.creds = creds
+	v.issued = issued
 
 	return creds, nil
 }
@@ -5,12 +5,13 @@ import (
 	"errors"
 	"net/url"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
-	"bou.ke/monkey"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/davepgreene/go-db-credential-refresh/driver"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -157,6 +158,19 @@ func TestValidStoreCanGenerateToken(t *testing.T) {
 	if creds.GetPassword() == "" {
 		t.Fatal("got empty password")
 	}
+
+	ec, ok := creds.(driver.ExpiringCredentials)
+	if !ok {
+		t.Fatalf("expected credentials to implement driver.ExpiringCredentials but got a %T", creds)
+	}
+
+	if remaining := time.Until(ec.ExpiresAt()); remaining <= 0 || remaining > tokenLifetime {
+		t.Fatalf("expected credentials to expire within %s but they expire in %s", tokenLifetime, remaining)
+	}
+
+	if tc, ok := creds.(driver.TokenCredentials); !ok || !tc.IsToken() {
+		t.Fatal("expected credentials to be marked as a login token")
+	}
 }
 
 func TestStoreErrorsOnUnsignableCredentials(t *testing.T) {
@@ -177,17 +191,31 @@ func TestStoreErrorsOnUnsignableCredentials(t *testing.T) {
 	}
 }
 
+// testClock is a clock tests move by hand.
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
 func TestStoreCachesCredentials(t *testing.T) {
 	s, err := NewStore(&Config{
-		Endpoint:    "rdsmysql.cdgmuqiadpid.us-east-1.rds.amazonaws.com:5432",
-		Region:      "us-east-1",
-		User:        "dbuser",
-		Credentials: credentials.NewStaticCredentialsProvider("foo", "bar", "baz"),
+		Endpoint:      "rdsmysql.cdgmuqiadpid.us-east-1.rds.amazonaws.com:5432",
+		Region:        "us-east-1",
+		User:          "dbuser",
+		Credentials:   credentials.NewStaticCredentialsProvider("foo", "bar", "baz"),
+		RefreshMargin: 2 * time.Minute,
 	})
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	clock := &testClock{now: time.Now()}
+	s.now = clock.Now
+	issued := clock.now
+
 	ctx := context.Background()
 
 	creds, err := s.Get(ctx)
@@ -195,54 +223,86 @@ func TestStoreCachesCredentials(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	username := creds.GetUsername()
-	if username == "" {
-		t.Fatal("got empty username")
+	if !s.IssuedAt().Equal(issued) {
+		t.Fatalf("expected '%v' but got '%v' instead", issued, s.IssuedAt())
 	}
 
-	password := creds.GetPassword()
-	if password == "" {
-		t.Fatal("got empty password")
-	}
+	// The token is cached until it is within the refresh margin of expiring.
+	clock.now = issued.Add(tokenLifetime - 2*time.Minute - time.Second)
 
-	// NOTE: This is hacky as hell but necessary because the rdsutil.BuildAuthToken has a hard-coded
-	// 15 minute expiration for each signed token. To ensure we don't repeatedly generate the same signing
-	// token we need to wind the clock forward past the 15 minute window.
-	// See https://github.com/aws/aws-sdk-go-v2/blob/main/feature/rds/auth/connect.go#L86
-	var patch *monkey.PatchGuard
+	cachedCreds, err := s.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	patch = monkey.Patch(time.Now, func() time.Time {
-		patch.Unpatch()
-		defer patch.Restore()
+	if cachedCreds != creds {
+		t.Fatalf("expected '%v' to be cached but got '%v' instead", creds, cachedCreds)
+	}
 
-		return time.Now().Add(20 * time.Minute)
-	})
-	defer patch.Unpatch()
+	clock.now = issued.Add(tokenLifetime - 2*time.Minute)
 
-	// Second time through we should have everything cached
-	cachedCreds, err := s.Get(ctx)
+	regeneratedCreds, err := s.Get(ctx)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	if username != cachedCreds.GetUsername() {
-		t.Fatalf("expected username to be cached but got %s instead", cachedCreds.GetUsername())
+	ec, ok := regeneratedCreds.(driver.ExpiringCredentials)
+	if !ok {
+		t.Fatalf("expected credentials to implement driver.ExpiringCredentials but got a %T", regeneratedCreds)
+	}
+
+	if expected := clock.now.Add(tokenLifetime); !ec.ExpiresAt().Equal(expected) {
+		t.Fatalf("expected a new token expiring at '%v' but got one expiring at '%v' instead", expected, ec.ExpiresAt())
+	}
+
+	// Refresh always builds a new token.
+	clock.now = clock.now.Add(time.Second)
+
+	if _, err := s.Refresh(ctx); err != nil {
+		t.Fatal(err)
 	}
-	if password != cachedCreds.GetPassword() {
-		t.Fatalf("expected password to be cached but got %s instead", cachedCreds.GetPassword())
+
+	if !s.IssuedAt().Equal(clock.now) {
+		t.Fatalf("expected '%v' but got '%v' instead", clock.now, s.IssuedAt())
 	}
+}
 
-	// On refresh, we should have a new password
-	refreshedCreds, err := s.Refresh(ctx)
+func TestStoreGetIsSafeForConcurrentUse(t *testing.T) {
+	s, err := NewStore(&Config{
+		Endpoint:    "rdsmysql.cdgmuqiadpid.us-east-1.rds.amazonaws.com:5432",
+		Region:      "us-east-1",
+		User:        "dbuser",
+		Credentials: credentials.NewStaticCredentialsProvider("foo", "bar", "baz"),
+	})
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	if password == refreshedCreds.GetPassword() {
-		t.Fatal(
-			"cached password and refreshed password were the same but expected them not to be",
-			password,
-			refreshedCreds.GetPassword(),
-		)
+	var wg sync.WaitGroup
+
+	for range 10 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if _, err := s.Get(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestStoreRejectsRefreshMarginLongerThanTokenLifetime(t *testing.T) {
+	if _, err := NewStore(&Config{
+		Endpoint:      "localhost:5432",
+		Region:        "us-east-1",
+		User:          "dbuser",
+		Credentials:   aws.AnonymousCredentials{},
+		RefreshMargin: tokenLifetime,
+	}); !errors.Is(err, errInvalidMargin) {
+		t.Fatalf("expected '%v' but got '%v' instead", errInvalidMargin, err)
 	}
 }
@@ -0,0 +1,136 @@
+package awsrds
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQLTLSConfigName is the name ConfigureTLS registers the RDS TLS configuration with in the MySQL
+// driver.
+const MySQLTLSConfigName = "rds"
+
+// certs holds the RDS global CA bundle. See certs/README.md.
+//
+//go:embed certs
+var certs embed.FS //nolint:gochecknoglobals
+
+var (
+	errMissingCABundle = errors.New("the RDS CA bundle isn't embedded, run make ca-bundle in store/awsrds")
+	errInvalidCABundle = errors.New("CA bundle has no PEM encoded certificates")
+)
+
+type errUnsupportedDriver struct {
+	name string
+}
+
+func (e errUnsupportedDriver) Error() string {
+	return fmt.Sprintf("can't configure TLS for driver %s. Must be one of: mysql, pgx, pgxv4, pq", e.name)
+}
+
+// CABundle returns the embedded AWS RDS global CA bundle in PEM format.
+func CABundle() ([]byte, error) {
+	var bundle []byte
+
+	err := fs.WalkDir(certs, "certs", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".pem" {
+			return err
+		}
+
+		b, err := certs.ReadFile(path)
+		bundle = append(bundle, b...)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bundle) == 0 {
+		return nil, errMissingCABundle
+	}
+
+	return bundle, nil
+}
+
+// ConfigureTLS sets up cfg so connections to RDS with IAM authentication, which requires TLS, verify
+// the server certificate against caBundle, or the embedded RDS CA bundle when caBundle is nil.
+//
+// For the mysql driver it registers a TLS configuration named MySQLTLSConfigName and sets the tls and
+// allowCleartextPasswords options, since the token is sent as a cleartext password over TLS. For the
+// Postgres drivers it writes the bundle to a file in a new private directory in the temporary
+// directory and sets the sslmode option to verify-full and the sslrootcert option to the file.
+func ConfigureTLS(driverName string, cfg *driver.Config, caBundle []byte) error {
+	if cfg == nil {
+		return driver.ErrConfigRequired
+	}
+
+	if caBundle == nil {
+		var err error
+		if caBundle, err = CABundle(); err != nil {
+			return err
+		}
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caBundle) {
+		return errInvalidCABundle
+	}
+
+	if cfg.Opts == nil {
+		cfg.Opts = make(map[string]string)
+	}
+
+	switch driverName {
+	case "mysql":
+		if err := mysql.RegisterTLSConfig(MySQLTLSConfigName, &tls.Config{
+			RootCAs:    pool,
+			MinVersion: tls.VersionTLS12,
+		}); err != nil {
+			return err
+		}
+
+		cfg.Opts["tls"] = MySQLTLSConfigName
+		cfg.Opts["allowCleartextPasswords"] = "true"
+	case "pgx", "pgxv4", "pq":
+		path, err := writeCABundle(caBundle)
+		if err != nil {
+			return err
+		}
+
+		cfg.Opts["sslmode"] = "verify-full"
+		cfg.Opts["sslrootcert"] = path
+	default:
+		return errUnsupportedDriver{driverName}
+	}
+
+	return nil
+}
+
+// writeCABundle writes caBundle to a new directory in the temporary directory, since the Postgres
+// drivers only read root certificates from files. The directory is created for this call and only
+// the current user can write to it, so another user on the host can't swap in their own bundle.
+func writeCABundle(caBundle []byte) (string, error) {
+	dir, err := os.MkdirTemp("", "rds-ca-")
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, "global-bundle.pem")
+
+	if err := os.WriteFile(path, caBundle, 0o600); err != nil {
+		_ = os.RemoveAll(dir)
+
+		return "", err
+	}
+
+	return path, nil
+}
@@ -0,0 +1,168 @@
+package awsrds
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+	"github.com/go-sql-driver/mysql"
+)
+
+func testCABundle(t *testing.T) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test RDS Root CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestConfigureTLSForMySQL(t *testing.T) {
+	cfg := &driver.Config{Opts: map[string]string{"parseTime": "true"}}
+
+	if err := ConfigureTLS("mysql", cfg, testCABundle(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{"parseTime": "true", "tls": MySQLTLSConfigName, "allowCleartextPasswords": "true"}
+	for k, v := range expected {
+		if cfg.Opts[k] != v {
+			t.Fatalf("expected '%s' but got '%s' instead for %s", v, cfg.Opts[k], k)
+		}
+	}
+
+	// The driver rejects DSNs naming TLS configurations that aren't registered.
+	dsn := driver.MysqlFormatter("app", "token", "mydb.example.com", 3306, "app", cfg.Opts)
+
+	mysqlCfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !mysqlCfg.AllowCleartextPasswords || mysqlCfg.TLS == nil || mysqlCfg.TLS.RootCAs == nil {
+		t.Fatalf("expected TLS with cleartext passwords but got '%s' instead", dsn)
+	}
+}
+
+func TestConfigureTLSForPostgres(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+
+	bundle := testCABundle(t)
+
+	for _, name := range []string{"pgx", "pgxv4", "pq"} {
+		t.Run(name, func(t *testing.T) {
+			cfg := &driver.Config{}
+
+			if err := ConfigureTLS(name, cfg, bundle); err != nil {
+				t.Fatal(err)
+			}
+
+			if cfg.Opts["sslmode"] != "verify-full" {
+				t.Fatalf("expected '%s' but got '%s' instead", "verify-full", cfg.Opts["sslmode"])
+			}
+
+			written, err := os.ReadFile(cfg.Opts["sslrootcert"])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(written) != string(bundle) {
+				t.Fatalf("expected '%s' but got '%s' instead", bundle, written)
+			}
+
+			// Only the current user can write the bundle or replace it.
+			for path, mode := range map[string]os.FileMode{
+				cfg.Opts["sslrootcert"]:               0o600,
+				filepath.Dir(cfg.Opts["sslrootcert"]): 0o700 | os.ModeDir,
+			} {
+				info, err := os.Stat(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if info.Mode() != mode {
+					t.Fatalf("expected '%v' but got '%v' instead", mode, info.Mode())
+				}
+			}
+		})
+	}
+}
+
+func TestConfigureTLSErrors(t *testing.T) {
+	testCases := []struct {
+		description string
+		driverName  string
+		cfg         *driver.Config
+		bundle      []byte
+		expectedErr error
+	}{
+		{
+			description: "missing config",
+			driverName:  "pgx",
+			bundle:      testCABundle(t),
+			expectedErr: driver.ErrConfigRequired,
+		},
+		{
+			description: "invalid bundle",
+			driverName:  "pgx",
+			cfg:         &driver.Config{},
+			bundle:      []byte("not a certificate"),
+			expectedErr: errInvalidCABundle,
+		},
+		{
+			description: "unsupported driver",
+			driverName:  "sqlite",
+			cfg:         &driver.Config{},
+			bundle:      testCABundle(t),
+			expectedErr: errUnsupportedDriver{"sqlite"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			if err := ConfigureTLS(tc.driverName, tc.cfg, tc.bundle); !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected '%v' but got '%v' instead", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+// TestCABundle fails until certs/global-bundle.pem is committed with make ca-bundle, since ConfigureTLS
+// can't verify RDS certificates without it.
+func TestCABundle(t *testing.T) {
+	bundle, err := CABundle()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !x509.NewCertPool().AppendCertsFromPEM(bundle) {
+		t.Fatal("expected the embedded bundle to hold PEM encoded certificates")
+	}
+}
@@ -0,0 +1,82 @@
+package awsredshift
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/redshift"
+	"github.com/aws/aws-sdk-go-v2/service/redshiftserverless"
+	"github.com/davepgreene/go-db-credential-refresh/store"
+)
+
+// getClusterCredentials issues credentials for a provisioned cluster with GetClusterCredentials, or
+// with GetClusterCredentialsWithIAM when no database user is configured.
+func (s *Store) getClusterCredentials(ctx context.Context) (*store.Credential, error) {
+	if s.DBUser == "" {
+		out, err := s.cluster.GetClusterCredentialsWithIAM(ctx, &redshift.GetClusterCredentialsWithIAMInput{
+			ClusterIdentifier: aws.String(s.ClusterIdentifier),
+			DbName:            s.dbName(),
+			DurationSeconds:   s.durationSeconds(),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return newCredential(out.DbUser, out.DbPassword, out.Expiration), nil
+	}
+
+	out, err := s.cluster.GetClusterCredentials(ctx, &redshift.GetClusterCredentialsInput{
+		ClusterIdentifier: aws.String(s.ClusterIdentifier),
+		DbUser:            aws.String(s.DBUser),
+		DbName:            s.dbName(),
+		DbGroups:          s.DBGroups,
+		AutoCreate:        aws.Bool(s.AutoCreate),
+		DurationSeconds:   s.durationSeconds(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newCredential(out.DbUser, out.DbPassword, out.Expiration), nil
+}
+
+// getServerlessCredentials issues credentials for a serverless workgroup with GetCredentials.
+func (s *Store) getServerlessCredentials(ctx context.Context) (*store.Credential, error) {
+	out, err := s.serverless.GetCredentials(ctx, &redshiftserverless.GetCredentialsInput{
+		WorkgroupName:   aws.String(s.WorkgroupName),
+		DbName:          s.dbName(),
+		DurationSeconds: s.durationSeconds(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newCredential(out.DbUser, out.DbPassword, out.Expiration), nil
+}
+
+// dbName returns the database to issue credentials for, or nil to leave it unset.
+func (s *Store) dbName() *string {
+	if s.DBName == "" {
+		return nil
+	}
+
+	return aws.String(s.DBName)
+}
+
+// durationSeconds returns Duration in seconds. NewStore limits it to an hour so it fits in an int32.
+func (s *Store) durationSeconds() *int32 {
+	return aws.Int32(int32(s.Duration / time.Second)) //nolint:gosec
+}
+
+// newCredential maps credentials issued by Redshift. Their password is only checked when a connection
+// is opened, so they are marked as a token and connections opened with them stay in the pool after
+// they expire.
+func newCredential(user, password *string, expiration *time.Time) *store.Credential {
+	return &store.Credential{
+		Username:   aws.ToString(user),
+		Password:   aws.ToString(password),
+		Expiration: aws.ToTime(expiration),
+		Token:      true,
+	}
+}
@@ -0,0 +1,36 @@
+package awsredshift
+
+import (
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+)
+
+const (
+	// DefaultPort is the port Redshift clusters and serverless workgroups listen on by default.
+	DefaultPort = 5439
+	// DefaultSSLMode is the sslmode Formatter uses when none is set. Redshift's certificates are
+	// issued by Amazon Trust Services, which system trust stores include, so the hostname and chain
+	// can be verified without a CA bundle.
+	DefaultSSLMode = "verify-full"
+)
+
+var _ driver.Formatter = Formatter
+
+// Formatter formats a connection URI for Redshift with the pgx and pq drivers, which speak the
+// PostgreSQL protocol Redshift implements. It uses DefaultPort when port is 0 and DefaultSSLMode when
+// opts doesn't set sslmode. Usernames issued through IAM like IAM:app are escaped in the URI.
+func Formatter(username, password, host string, port int, db string, opts map[string]string) string {
+	if port == 0 {
+		port = DefaultPort
+	}
+
+	o := make(map[string]string, len(opts)+1)
+	for k, v := range opts {
+		o[k] = v
+	}
+
+	if o["sslmode"] == "" {
+		o["sslmode"] = DefaultSSLMode
+	}
+
+	return driver.PgFormatter(username, password, host, port, db, o)
+}
@@ -0,0 +1,124 @@
+package awsredshift
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+	"github.com/davepgreene/go-db-credential-refresh/store"
+)
+
+// StoreName is the name the Redshift store is registered with in the store package.
+const StoreName = "awsredshift"
+
+func init() { //nolint:gochecknoinits
+	if err := store.Register(StoreName, NewStoreFromURL); err != nil {
+		panic(err)
+	}
+}
+
+// NewStoreFromURL creates a Redshift store from a URL like
+//
+//	awsredshift://mycluster?region=us-east-1&user=app&db=dev
+//	awsredshift://?workgroup=analytics&region=us-east-1&db=dev
+//
+// It is registered with the store package so importing this package makes awsredshift:// URIs
+// available to store.Open. The host of the URL is the identifier of a provisioned cluster. AWS
+// credentials come from the default credential chain. The query accepts:
+//
+//   - cluster: the cluster identifier when the URL has no host.
+//   - workgroup: the Redshift Serverless workgroup instead of a cluster.
+//   - db: the database the credentials are for.
+//   - user: the database user of a cluster. The IAM identity is mapped to a user when it isn't set.
+//   - groups: a comma-separated list of groups the user joins for the session.
+//   - auto_create: whether to create the user if it doesn't exist.
+//   - duration: how long issued credentials are valid for, e.g. 30m.
+//   - refresh_margin: how long before credentials expire that new ones are issued, e.g. 2m.
+//   - region: the region of the cluster. It defaults to the region of the AWS configuration.
+//   - profile: the shared configuration profile to load credentials from.
+//   - endpoint: the Redshift API endpoint, e.g. for a VPC endpoint.
+func NewStoreFromURL(ctx context.Context, u *url.URL) (driver.Store, error) {
+	q := u.Query()
+
+	if err := store.CheckParams(StoreName, q, "cluster", "workgroup", "db", "user", "groups", "auto_create",
+		"duration", "refresh_margin", "region", "profile", "endpoint"); err != nil {
+		return nil, err
+	}
+
+	cluster := u.Host
+	if cluster == "" {
+		cluster = q.Get("cluster")
+	}
+
+	workgroup := q.Get("workgroup")
+	if cluster == "" && workgroup == "" {
+		return nil, paramError("cluster", store.ErrMissingParam)
+	}
+
+	var groups []string
+	if v := q.Get("groups"); v != "" {
+		groups = strings.Split(v, ",")
+	}
+
+	var autoCreate bool
+	if v := q.Get("auto_create"); v != "" {
+		var err error
+		if autoCreate, err = strconv.ParseBool(v); err != nil {
+			return nil, paramError("auto_create", err)
+		}
+	}
+
+	durations := map[string]time.Duration{}
+	for _, param := range []string{"duration", "refresh_margin"} {
+		if v := q.Get(param); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, paramError(param, err)
+			}
+
+			durations[param] = d
+		}
+	}
+
+	var opts []func(*config.LoadOptions) error
+	if region := q.Get("region"); region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+
+	if profile := q.Get("profile"); profile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(profile))
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if awsCfg.Region == "" {
+		return nil, paramError("region", store.ErrMissingParam)
+	}
+
+	if endpoint := q.Get("endpoint"); endpoint != "" {
+		awsCfg.BaseEndpoint = &endpoint
+	}
+
+	return NewStore(&Config{
+		AWSConfig:         awsCfg,
+		ClusterIdentifier: cluster,
+		WorkgroupName:     workgroup,
+		DBName:            q.Get("db"),
+		DBUser:            q.Get("user"),
+		DBGroups:          groups,
+		AutoCreate:        autoCreate,
+		Duration:          durations["duration"],
+		RefreshMargin:     durations["refresh_margin"],
+	})
+}
+
+func paramError(param string, err error) error {
+	return &store.ParamError{Store: StoreName, Param: param, Err: err}
+}
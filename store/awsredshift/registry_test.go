@@ -0,0 +1,132 @@
+package awsredshift
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+	"github.com/davepgreene/go-db-credential-refresh/store"
+)
+
+// isolateAWSConfig stops tests from picking up the AWS configuration of the machine they run on.
+func isolateAWSConfig(t *testing.T) {
+	t.Helper()
+
+	t.Setenv("AWS_CONFIG_FILE", t.TempDir()+"/config")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", t.TempDir()+"/credentials")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "SECRET")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	t.Setenv("AWS_PROFILE", "")
+}
+
+func TestStoreIsRegistered(t *testing.T) {
+	isolateAWSConfig(t)
+
+	f, awsCfg := newFakeRedshift(t, time.Now())
+	endpoint := *awsCfg.BaseEndpoint
+	ctx := context.Background()
+
+	fromURI, err := store.Open(ctx, "awsredshift://"+clusterID+
+		"?region=us-east-1&user=app&db=dev&groups=readers,writers&auto_create=true&duration=30m&endpoint="+
+		url.QueryEscape(endpoint))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromMap, err := store.OpenMap(ctx, StoreName, map[string]string{
+		"workgroup": workgroup,
+		"region":    "us-east-1",
+		"endpoint":  endpoint,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []driver.Store{fromURI, fromMap} {
+		if _, err := s.Get(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rs, ok := fromURI.(*Store)
+	if !ok {
+		t.Fatalf("expected '%T' but got '%T' instead", &Store{}, fromURI)
+	}
+
+	if rs.Duration != 30*time.Minute || !rs.AutoCreate || len(rs.DBGroups) != 2 {
+		t.Fatalf("expected the query to configure the store but got '%+v' instead", rs.Config)
+	}
+
+	if f.calls() != 2 {
+		t.Fatalf("expected '%d' but got '%d' instead", 2, f.calls())
+	}
+}
+
+func TestNewStoreFromURLReportsMissingParams(t *testing.T) {
+	isolateAWSConfig(t)
+
+	testCases := []struct {
+		description string
+		uri         string
+		param       string
+		err         error
+	}{
+		{
+			description: "missing cluster",
+			uri:         "awsredshift://?region=us-east-1",
+			param:       "cluster",
+			err:         store.ErrMissingParam,
+		},
+		{
+			description: "missing region",
+			uri:         "awsredshift://mycluster",
+			param:       "region",
+			err:         store.ErrMissingParam,
+		},
+		{
+			description: "invalid auto create",
+			uri:         "awsredshift://mycluster?region=us-east-1&auto_create=maybe",
+			param:       "auto_create",
+		},
+		{
+			description: "invalid duration",
+			uri:         "awsredshift://mycluster?region=us-east-1&duration=long",
+			param:       "duration",
+		},
+		{
+			description: "invalid refresh margin",
+			uri:         "awsredshift://mycluster?region=us-east-1&refresh_margin=soon",
+			param:       "refresh_margin",
+		},
+		{
+			description: "unknown parameter",
+			uri:         "awsredshift://mycluster?region=us-east-1&database=dev",
+			param:       "database",
+			err:         store.ErrUnknownParam,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			_, err := store.Open(context.Background(), tc.uri)
+
+			var paramErr *store.ParamError
+			if !errors.As(err, &paramErr) {
+				t.Fatalf("expected '%T' but got '%v' instead", paramErr, err)
+			}
+
+			if paramErr.Param != tc.param {
+				t.Fatalf("expected '%s' but got '%s' instead", tc.param, paramErr.Param)
+			}
+
+			if tc.err != nil && !errors.Is(err, tc.err) {
+				t.Fatalf("expected '%v' but got '%v' instead", tc.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+// Package awsredshift provides a Store that issues temporary Amazon Redshift database credentials
+// through IAM, with GetClusterCredentials for provisioned clusters and GetCredentials for Redshift
+// Serverless workgroups.
+package awsredshift
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/redshift"
+	"github.com/aws/aws-sdk-go-v2/service/redshiftserverless"
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+	"github.com/davepgreene/go-db-credential-refresh/store"
+)
+
+const (
+	// DefaultDuration is the default value of Config.Duration.
+	DefaultDuration = 15 * time.Minute
+	// DefaultRefreshMargin is the default value of Config.RefreshMargin.
+	DefaultRefreshMargin = time.Minute
+
+	// Redshift issues credentials that are valid for between 15 minutes and an hour.
+	minDuration = 15 * time.Minute
+	maxDuration = time.Hour
+)
+
+var (
+	errMissingConfig      = errors.New("config is required")
+	errMissingTarget      = errors.New("cluster identifier or workgroup name is required")
+	errAmbiguousTarget    = errors.New("only one of cluster identifier and workgroup name can be set")
+	errMissingRegion      = errors.New("region is required")
+	errMissingCredentials = errors.New("credentials cannot be nil")
+	errClusterOnly        = errors.New("database user, groups and auto create only apply to clusters")
+	errInvalidDuration    = fmt.Errorf("duration must be between %s and %s", minDuration, maxDuration)
+	errInvalidMargin      = errors.New("refresh margin must be shorter than the duration")
+)
+
+// Config contains configuration information.
+type Config struct {
+	// AWSConfig provides the region, credentials and HTTP client the Redshift APIs are called with.
+	// Its BaseEndpoint can point them at a VPC endpoint.
+	AWSConfig aws.Config
+	// ClusterIdentifier is the provisioned cluster to issue credentials for. Exactly one of it and
+	// WorkgroupName must be set.
+	ClusterIdentifier string
+	// WorkgroupName is the Redshift Serverless workgroup to issue credentials for.
+	WorkgroupName string
+	// DBName is the database the credentials are for. It is optional.
+	DBName string
+	// DBUser is the database user of a cluster to issue credentials for with GetClusterCredentials.
+	// When it is empty GetClusterCredentialsWithIAM maps the IAM identity to a user instead.
+	DBUser string
+	// DBGroups are the groups DBUser joins for the session and AutoCreate creates DBUser if it doesn't
+	// exist. They only apply when DBUser is set.
+	DBGroups   []string
+	AutoCreate bool
+	// Duration is how long issued credentials are valid for. It defaults to DefaultDuration and must
+	// be between 15 minutes and an hour.
+	Duration time.Duration
+	// RefreshMargin is how long before credentials expire that Get issues new ones. It defaults to
+	// DefaultRefreshMargin.
+	RefreshMargin time.Duration
+	// Logger logs when credentials are issued. Passwords are never logged. It is optional.
+	Logger *slog.Logger
+}
+
+// Store is a Store implementation for Amazon Redshift IAM authentication. The credentials it returns
+// are *store.Credential values whose Expiration is the one Redshift issued them with.
+type Store struct {
+	*Config
+	// now is the clock expiry is checked with. Tests replace it.
+	now        func() time.Time
+	cluster    *redshift.Client
+	serverless *redshiftserverless.Client
+
+	mu    sync.Mutex
+	creds *store.Credential
+}
+
+// NewStore creates a new Redshift-backed store.
+func NewStore(c *Config) (*Store, error) {
+	if c == nil {
+		return nil, errMissingConfig
+	}
+
+	switch {
+	case c.ClusterIdentifier == "" && c.WorkgroupName == "":
+		return nil, errMissingTarget
+	case c.ClusterIdentifier != "" && c.WorkgroupName != "":
+		return nil, errAmbiguousTarget
+	case c.WorkgroupName != "" && (c.DBUser != "" || len(c.DBGroups) > 0 || c.AutoCreate):
+		return nil, errClusterOnly
+	}
+
+	if c.AWSConfig.Region == "" {
+		return nil, errMissingRegion
+	}
+
+	if c.AWSConfig.Credentials == nil {
+		return nil, errMissingCredentials
+	}
+
+	if c.Duration == 0 {
+		c.Duration = DefaultDuration
+	}
+
+	if c.Duration < minDuration || c.Duration > maxDuration {
+		return nil, errInvalidDuration
+	}
+
+	if c.RefreshMargin <= 0 {
+		c.RefreshMargin = DefaultRefreshMargin
+	}
+
+	if c.RefreshMargin >= c.Duration {
+		return nil, errInvalidMargin
+	}
+
+	return &Store{
+		Config:     c,
+		now:        time.Now,
+		cluster:    redshift.NewFromConfig(c.AWSConfig),
+		serverless: redshiftserverless.NewFromConfig(c.AWSConfig),
+	}, nil
+}
+
+// Get implements the Store interface. The cached credentials are returned until they are within
+// RefreshMargin of expiring, when new ones are issued.
+func (s *Store) Get(ctx context.Context) (driver.Credentials, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.creds != nil && s.now().Before(s.creds.Expiration.Add(-s.RefreshMargin)) {
+		return s.creds, nil
+	}
+
+	return s.issue(ctx)
+}
+
+// Refresh implements the Store interface.
+func (s *Store) Refresh(ctx context.Context) (driver.Credentials, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.issue(ctx)
+}
+
+// issue issues new credentials and caches them. It must be called with s.mu held.
+func (s *Store) issue(ctx context.Context) (*store.Credential, error) {
+	var (
+		creds *store.Credential
+		err   error
+	)
+
+	if s.WorkgroupName != "" {
+		creds, err = s.getServerlessCredentials(ctx)
+	} else {
+		creds, err = s.getClusterCredentials(ctx)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Logger != nil {
+		s.Logger.DebugContext(ctx, "issued Redshift credentials", "cluster", s.ClusterIdentifier,
+			"workgroup", s.WorkgroupName, "user", creds.Username, "expiration", creds.Expiration)
+	}
+
+	s.creds = creds
+
+	return creds, nil
+}
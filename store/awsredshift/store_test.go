@@ -0,0 +1,422 @@
+package awsredshift
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/smithy-go"
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+)
+
+const (
+	clusterID = "mycluster"
+	workgroup = "analytics"
+)
+
+// fakeRedshift is an httptest stand-in for the GetClusterCredentials and GetClusterCredentialsWithIAM
+// actions of the Redshift Query API and the GetCredentials action of the Redshift Serverless JSON
+// API. Each call issues a new password that expires after the requested duration.
+type fakeRedshift struct {
+	now time.Time
+
+	mu       sync.Mutex
+	requests []*http.Request
+	forms    []map[string][]string
+}
+
+func newFakeRedshift(t *testing.T, now time.Time) (*fakeRedshift, aws.Config) {
+	t.Helper()
+
+	f := &fakeRedshift{now: now}
+	ts := httptest.NewServer(f)
+	t.Cleanup(ts.Close)
+
+	return f, aws.Config{
+		Region:       "us-east-1",
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		BaseEndpoint: aws.String(ts.URL),
+		HTTPClient:   ts.Client(),
+	}
+}
+
+func (f *fakeRedshift) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, r)
+	password := fmt.Sprintf("password%d", len(f.requests))
+
+	if r.Header.Get("X-Amz-Target") == "RedshiftServerless.GetCredentials" {
+		var in struct {
+			WorkgroupName   string `json:"workgroupName"`
+			DBName          string `json:"dbName"`
+			DurationSeconds int    `json:"durationSeconds"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		f.forms = append(f.forms, map[string][]string{"workgroupName": {in.WorkgroupName}, "dbName": {in.DBName}})
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+
+		if in.WorkgroupName != workgroup {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"__type": "com.amazonaws.redshiftserverless#ResourceNotFoundException", "message": "nope"}`)
+
+			return
+		}
+
+		exp := f.now.Add(time.Duration(in.DurationSeconds) * time.Second)
+
+		//nolint:errchkjson
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"dbUser":     "IAMR:app",
+			"dbPassword": password,
+			"expiration": float64(exp.UnixMilli()) / 1000,
+		})
+
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	f.forms = append(f.forms, r.PostForm)
+
+	if r.PostForm.Get("ClusterIdentifier") != clusterID {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `<ErrorResponse><Error><Type>Sender</Type><Code>ClusterNotFound</Code>`+
+			`<Message>Cluster not found</Message></Error><RequestId>1</RequestId></ErrorResponse>`)
+
+		return
+	}
+
+	var duration time.Duration
+	if _, err := fmt.Sscanf(r.PostForm.Get("DurationSeconds"), "%d", &duration); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	action := r.PostForm.Get("Action")
+	user := "IAM:" + r.PostForm.Get("DbUser")
+
+	if action == "GetClusterCredentialsWithIAM" {
+		user = "IAMR:app"
+	}
+
+	fmt.Fprintf(w, `<%[1]sResponse xmlns="http://redshift.amazonaws.com/doc/2012-12-01/">
+  <%[1]sResult>
+    <DbUser>%[2]s</DbUser>
+    <Expiration>%[3]s</Expiration>
+    <DbPassword>%[4]s</DbPassword>
+  </%[1]sResult>
+  <ResponseMetadata><RequestId>1</RequestId></ResponseMetadata>
+</%[1]sResponse>`, action, user, f.now.Add(duration*time.Second).Format(time.RFC3339Nano), password)
+}
+
+func (f *fakeRedshift) calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.requests)
+}
+
+func (f *fakeRedshift) lastForm() map[string][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.forms[len(f.forms)-1]
+}
+
+func (f *fakeRedshift) lastRequest() *http.Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.requests[len(f.requests)-1]
+}
+
+func TestStoreValidation(t *testing.T) {
+	if _, err := NewStore(nil); !errors.Is(err, errMissingConfig) {
+		t.Fatalf("expected '%v' but got '%v' instead", errMissingConfig, err)
+	}
+
+	awsCfg := aws.Config{Region: "us-east-1", Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", "")}
+
+	testCases := []struct {
+		description string
+		config      *Config
+		expectedErr error
+	}{
+		{
+			description: "missing target",
+			config:      &Config{AWSConfig: awsCfg},
+			expectedErr: errMissingTarget,
+		},
+		{
+			description: "cluster and workgroup",
+			config:      &Config{AWSConfig: awsCfg, ClusterIdentifier: clusterID, WorkgroupName: workgroup},
+			expectedErr: errAmbiguousTarget,
+		},
+		{
+			description: "user with workgroup",
+			config:      &Config{AWSConfig: awsCfg, WorkgroupName: workgroup, DBUser: "app"},
+			expectedErr: errClusterOnly,
+		},
+		{
+			description: "missing region",
+			config:      &Config{AWSConfig: aws.Config{Credentials: awsCfg.Credentials}, ClusterIdentifier: clusterID},
+			expectedErr: errMissingRegion,
+		},
+		{
+			description: "missing credentials",
+			config:      &Config{AWSConfig: aws.Config{Region: "us-east-1"}, ClusterIdentifier: clusterID},
+			expectedErr: errMissingCredentials,
+		},
+		{
+			description: "duration too short",
+			config:      &Config{AWSConfig: awsCfg, ClusterIdentifier: clusterID, Duration: time.Minute},
+			expectedErr: errInvalidDuration,
+		},
+		{
+			description: "duration too long",
+			config:      &Config{AWSConfig: awsCfg, ClusterIdentifier: clusterID, Duration: 2 * time.Hour},
+			expectedErr: errInvalidDuration,
+		},
+		{
+			description: "refresh margin too long",
+			config:      &Config{AWSConfig: awsCfg, ClusterIdentifier: clusterID, RefreshMargin: time.Hour},
+			expectedErr: errInvalidMargin,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			if _, err := NewStore(tc.config); !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected '%v' but got '%v' instead", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestClusterCredentials(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	f, awsCfg := newFakeRedshift(t, now)
+
+	s, err := NewStore(&Config{
+		AWSConfig:         awsCfg,
+		ClusterIdentifier: clusterID,
+		DBName:            "dev",
+		DBUser:            "app",
+		DBGroups:          []string{"readers", "writers"},
+		AutoCreate:        true,
+		Duration:          30 * time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.now = func() time.Time { return now }
+
+	creds, err := s.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectCredentials(t, creds, "IAM:app", "password1", now.Add(30*time.Minute))
+
+	auth := f.lastRequest().Header.Get("Authorization")
+	if !strings.Contains(auth, "/us-east-1/redshift/aws4_request") {
+		t.Fatalf("expected a request signed for redshift but got '%s' instead", auth)
+	}
+
+	form := f.lastForm()
+	for k, v := range map[string]string{
+		"Action":             "GetClusterCredentials",
+		"Version":            "2012-12-01",
+		"ClusterIdentifier":  clusterID,
+		"DbName":             "dev",
+		"DbUser":             "app",
+		"AutoCreate":         "true",
+		"DurationSeconds":    "1800",
+		"DbGroups.DbGroup.1": "readers",
+		"DbGroups.DbGroup.2": "writers",
+	} {
+		if len(form[k]) != 1 || form[k][0] != v {
+			t.Fatalf("expected '%s' to be '%s' but got '%v' instead", k, v, form[k])
+		}
+	}
+}
+
+func TestClusterCredentialsWithIAM(t *testing.T) {
+	now := time.Now()
+	f, awsCfg := newFakeRedshift(t, now)
+
+	s, err := NewStore(&Config{AWSConfig: awsCfg, ClusterIdentifier: clusterID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	creds, err := s.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectCredentials(t, creds, "IAMR:app", "password1", now.Add(DefaultDuration))
+
+	form := f.lastForm()
+	if action := form["Action"]; len(action) != 1 || action[0] != "GetClusterCredentialsWithIAM" {
+		t.Fatalf("expected '%s' but got '%v' instead", "GetClusterCredentialsWithIAM", action)
+	}
+
+	if _, ok := form["DbUser"]; ok {
+		t.Fatalf("expected no DbUser but got '%v' instead", form["DbUser"])
+	}
+}
+
+func TestServerlessCredentials(t *testing.T) {
+	now := time.Now()
+	f, awsCfg := newFakeRedshift(t, now)
+
+	s, err := NewStore(&Config{AWSConfig: awsCfg, WorkgroupName: workgroup, DBName: "dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	creds, err := s.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectCredentials(t, creds, "IAMR:app", "password1", now.Add(DefaultDuration))
+
+	auth := f.lastRequest().Header.Get("Authorization")
+	if !strings.Contains(auth, "/us-east-1/redshift-serverless/aws4_request") {
+		t.Fatalf("expected a request signed for redshift-serverless but got '%s' instead", auth)
+	}
+
+	if db := f.lastForm()["dbName"]; len(db) != 1 || db[0] != "dev" {
+		t.Fatalf("expected '%s' but got '%v' instead", "dev", db)
+	}
+}
+
+func TestGetCachesUntilRefreshMargin(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	f, awsCfg := newFakeRedshift(t, now)
+
+	s, err := NewStore(&Config{AWSConfig: awsCfg, ClusterIdentifier: clusterID, DBUser: "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock := now
+	s.now = func() time.Time { return clock }
+	ctx := context.Background()
+
+	testCases := []struct {
+		description string
+		elapsed     time.Duration
+		refresh     bool
+		calls       int
+	}{
+		{description: "first get", calls: 1},
+		{description: "cached", elapsed: 13 * time.Minute, calls: 1},
+		{description: "within margin", elapsed: 14*time.Minute + time.Second, calls: 2},
+		{description: "refresh", elapsed: 14*time.Minute + 2*time.Second, refresh: true, calls: 3},
+	}
+
+	for _, tc := range testCases {
+		clock = now.Add(tc.elapsed)
+
+		get := s.Get
+		if tc.refresh {
+			get = s.Refresh
+		}
+
+		if _, err := get(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		if f.calls() != tc.calls {
+			t.Fatalf("%s: expected '%d' but got '%d' instead", tc.description, tc.calls, f.calls())
+		}
+	}
+}
+
+func TestAPIErrors(t *testing.T) {
+	_, awsCfg := newFakeRedshift(t, time.Now())
+
+	testCases := []struct {
+		description string
+		config      *Config
+		code        string
+	}{
+		{
+			description: "cluster",
+			config:      &Config{AWSConfig: awsCfg, ClusterIdentifier: "missing"},
+			code:        "ClusterNotFound",
+		},
+		{
+			description: "serverless",
+			config:      &Config{AWSConfig: awsCfg, WorkgroupName: "missing"},
+			code:        "ResourceNotFoundException",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			s, err := NewStore(tc.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = s.Get(context.Background())
+
+			var apiErr smithy.APIError
+			if !errors.As(err, &apiErr) || apiErr.ErrorCode() != tc.code {
+				t.Fatalf("expected '%s' but got '%v' instead", tc.code, err)
+			}
+		})
+	}
+}
+
+func expectCredentials(t *testing.T, creds driver.Credentials, username, password string, expiration time.Time) {
+	t.Helper()
+
+	if creds.GetUsername() != username {
+		t.Fatalf("expected '%s' but got '%s' instead", username, creds.GetUsername())
+	}
+
+	if creds.GetPassword() != password {
+		t.Fatalf("expected '%s' but got '%s' instead", password, creds.GetPassword())
+	}
+
+	ec, ok := creds.(driver.ExpiringCredentials)
+	if !ok {
+		t.Fatalf("expected '%T' to implement driver.ExpiringCredentials", creds)
+	}
+
+	if got := ec.ExpiresAt(); got.Sub(expiration).Abs() > time.Millisecond {
+		t.Fatalf("expected '%v' but got '%v' instead", expiration, got)
+	}
+
+	if tc, ok := creds.(driver.TokenCredentials); !ok || !tc.IsToken() {
+		t.Fatal("expected credentials to be marked as a login token")
+	}
+}
@@ -0,0 +1,96 @@
+package awssecretsmanager
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+	"github.com/davepgreene/go-db-credential-refresh/store"
+)
+
+// StoreName is the name the Secrets Manager store is registered with in the store package.
+const StoreName = "awssecretsmanager"
+
+func init() { //nolint:gochecknoinits
+	if err := store.Register(StoreName, NewStoreFromURL); err != nil {
+		panic(err)
+	}
+}
+
+// NewStoreFromURL creates a Secrets Manager store from a URL like
+//
+//	awssecretsmanager://prod/app/db?region=us-east-1
+//
+// It is registered with the store package so importing this package makes awssecretsmanager:// URIs
+// available to store.Open. The host and path of the URL are the name of the secret. AWS credentials
+// come from the default credential chain. The query accepts:
+//
+//   - secret_id: the name or ARN of the secret when the URL has no host.
+//   - region: the region of the secret. It defaults to the region of the AWS configuration.
+//   - profile: the shared configuration profile to load credentials from.
+//   - endpoint: the Secrets Manager endpoint, e.g. for a VPC endpoint or a local emulator.
+//   - username_field and password_field: the JSON fields holding the credentials. They default to
+//     username and password.
+func NewStoreFromURL(ctx context.Context, u *url.URL) (driver.Store, error) {
+	q := u.Query()
+
+	if err := store.CheckParams(StoreName, q, "secret_id", "region", "profile", "endpoint", "username_field",
+		"password_field"); err != nil {
+		return nil, err
+	}
+
+	secretID := strings.TrimSuffix(u.Host+u.Path, "/")
+	if secretID == "" {
+		secretID = q.Get("secret_id")
+	}
+
+	if secretID == "" {
+		return nil, paramError("secret_id", store.ErrMissingParam)
+	}
+
+	var opts []func(*config.LoadOptions) error
+	if region := q.Get("region"); region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+
+	if profile := q.Get("profile"); profile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(profile))
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if awsCfg.Region == "" {
+		return nil, paramError("region", store.ErrMissingParam)
+	}
+
+	client := secretsmanager.NewFromConfig(awsCfg, func(o *secretsmanager.Options) {
+		if endpoint := q.Get("endpoint"); endpoint != "" {
+			o.BaseEndpoint = &endpoint
+		}
+	})
+
+	usernameField, passwordField := q.Get("username_field"), q.Get("password_field")
+	if usernameField == "" {
+		usernameField = "username"
+	}
+
+	if passwordField == "" {
+		passwordField = "password"
+	}
+
+	return NewStore(&Config{
+		Client:   client,
+		SecretID: secretID,
+		Mapper:   store.JSONFieldMapper(usernameField, passwordField),
+	})
+}
+
+func paramError(param string, err error) error {
+	return &store.ParamError{Store: StoreName, Param: param, Err: err}
+}
@@ -0,0 +1,98 @@
+package awssecretsmanager
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+	"github.com/davepgreene/go-db-credential-refresh/store"
+)
+
+// isolateAWSConfig stops tests from picking up the AWS configuration of the machine they run on.
+func isolateAWSConfig(t *testing.T) {
+	t.Helper()
+
+	t.Setenv("AWS_CONFIG_FILE", t.TempDir()+"/config")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", t.TempDir()+"/credentials")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "SECRET")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	t.Setenv("AWS_PROFILE", "")
+}
+
+func TestStoreIsRegistered(t *testing.T) {
+	isolateAWSConfig(t)
+
+	_, ts := newFakeSecretsManager(t, map[string]string{StageCurrent: `{"user": "app", "password": "secret"}`})
+
+	ctx := context.Background()
+
+	fromURI, err := store.Open(ctx, "awssecretsmanager://"+secretID+"?region=us-east-1&username_field=user&endpoint="+
+		url.QueryEscape(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromMap, err := store.OpenMap(ctx, StoreName, map[string]string{
+		"secret_id":      secretID,
+		"region":         "us-east-1",
+		"endpoint":       ts.URL,
+		"username_field": "user",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []driver.Store{fromURI, fromMap} {
+		creds, err := s.Get(ctx)
+		expectPassword(t, creds, err, "secret")
+	}
+}
+
+func TestNewStoreFromURLReportsInvalidParams(t *testing.T) {
+	isolateAWSConfig(t)
+
+	testCases := []struct {
+		description string
+		uri         string
+		param       string
+		err         error
+	}{
+		{
+			description: "missing secret ID",
+			uri:         "awssecretsmanager://?region=us-east-1",
+			param:       "secret_id",
+			err:         store.ErrMissingParam,
+		},
+		{
+			description: "missing region",
+			uri:         "awssecretsmanager://" + secretID,
+			param:       "region",
+			err:         store.ErrMissingParam,
+		},
+		{
+			description: "unknown parameter",
+			uri:         "awssecretsmanager://" + secretID + "?region=us-east-1&user_field=user",
+			param:       "user_field",
+			err:         store.ErrUnknownParam,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			_, err := store.Open(context.Background(), tc.uri)
+
+			var paramErr *store.ParamError
+			if !errors.As(err, &paramErr) {
+				t.Fatalf("expected '%T' but got '%v' instead", paramErr, err)
+			}
+
+			if paramErr.Param != tc.param || !errors.Is(err, tc.err) {
+				t.Fatalf("expected '%s' to be reported but got '%v' instead", tc.param, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+// Package awssecretsmanager provides a Store that reads database credentials from a JSON secret in
+// AWS Secrets Manager, like the ones the Secrets Manager rotation functions for RDS maintain.
+package awssecretsmanager
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+	"github.com/davepgreene/go-db-credential-refresh/store"
+)
+
+// Staging labels Secrets Manager attaches to the versions of a secret during rotation.
+// See https://docs.aws.amazon.com/secretsmanager/latest/userguide/rotate-secrets_how.html
+const (
+	StageCurrent  = "AWSCURRENT"
+	StagePending  = "AWSPENDING"
+	StagePrevious = "AWSPREVIOUS"
+)
+
+var (
+	errMissingConfig   = errors.New("config is required")
+	errMissingClient   = errors.New("client is required")
+	errMissingSecretID = errors.New("secret ID is required")
+	errMissingString   = errors.New("secret has no string value")
+)
+
+// Client is the part of the Secrets Manager API the Store uses. *secretsmanager.Client implements it.
+type Client interface {
+	GetSecretValue(
+		ctx context.Context,
+		params *secretsmanager.GetSecretValueInput,
+		optFns ...func(*secretsmanager.Options),
+	) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// Config contains configuration information.
+type Config struct {
+	Client Client
+	// SecretID is the name or ARN of the secret.
+	SecretID string
+	// Mapper maps the secret string to credentials. It defaults to store.JSONMapper, which reads the
+	// username and password fields RDS secrets use. See store.JSONFieldMapper for other field names.
+	Mapper store.Mapper
+	// RefreshStages are the staging labels Refresh tries in order until it finds credentials other
+	// than the ones the database rejected. It defaults to AWSCURRENT then AWSPENDING, so connections
+	// keep working while a rotation has changed the password in the database but not yet promoted
+	// the new version of the secret.
+	RefreshStages []string
+	// Logger logs which version of the secret credentials were read from. Passwords are never
+	// logged. It is optional.
+	Logger *slog.Logger
+}
+
+// Store is a Store implementation for AWS Secrets Manager.
+type Store struct {
+	*Config
+
+	mu    sync.Mutex
+	creds *store.Credential
+}
+
+// NewStore creates a new Secrets Manager-backed store.
+func NewStore(c *Config) (*Store, error) {
+	if c == nil {
+		return nil, errMissingConfig
+	}
+
+	if c.Client == nil {
+		return nil, errMissingClient
+	}
+
+	if c.SecretID == "" {
+		return nil, errMissingSecretID
+	}
+
+	if c.Mapper == nil {
+		c.Mapper = store.JSONMapper
+	}
+
+	if len(c.RefreshStages) == 0 {
+		c.RefreshStages = []string{StageCurrent, StagePending}
+	}
+
+	return &Store{
+		Config: c,
+	}, nil
+}
+
+// Get implements the Store interface. It reads the AWSCURRENT version of the secret and caches it.
+func (s *Store) Get(ctx context.Context) (driver.Credentials, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.creds != nil {
+		return s.creds, nil
+	}
+
+	creds, err := s.read(ctx, StageCurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	s.creds = creds
+
+	return creds, nil
+}
+
+// Refresh implements the Store interface. The Connector refreshes credentials when the database
+// rejects them, so Refresh returns the first version of the secret in RefreshStages that differs
+// from the credentials it last returned. Stages the secret doesn't have are skipped. When every
+// version holds the same credentials the first one is returned.
+func (s *Store) Refresh(ctx context.Context) (driver.Credentials, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		first    *store.Credential
+		firstErr error
+	)
+
+	for _, stage := range s.RefreshStages {
+		creds, err := s.read(ctx, stage)
+
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) && first != nil {
+			continue
+		}
+
+		if first == nil && firstErr == nil {
+			first, firstErr = creds, err
+		}
+
+		if err != nil {
+			continue
+		}
+
+		if s.creds == nil || creds.Username != s.creds.Username || creds.Password != s.creds.Password {
+			s.creds = creds
+
+			return creds, nil
+		}
+	}
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	s.creds = first
+
+	return first, nil
+}
+
+// read reads and maps the version of the secret with the staging label stage.
+func (s *Store) read(ctx context.Context, stage string) (*store.Credential, error) {
+	out, err := s.Client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId:     aws.String(s.SecretID),
+		VersionStage: aws.String(stage),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if out.SecretString == nil {
+		return nil, errMissingString
+	}
+
+	creds, err := s.Mapper(*out.SecretString)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Logger != nil {
+		s.Logger.DebugContext(ctx, "read secret", "secret_id", s.SecretID, "stage", stage,
+			"version_id", aws.ToString(out.VersionId), "username", creds.Username)
+	}
+
+	return creds, nil
+}
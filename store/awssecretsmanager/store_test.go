@@ -0,0 +1,246 @@
+package awssecretsmanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+	"github.com/davepgreene/go-db-credential-refresh/store"
+)
+
+const (
+	secretID = "prod/app/db"
+	username = "app"
+)
+
+// fakeSecretsManager is an httptest stand-in for the GetSecretValue action of the Secrets Manager
+// API. It serves the secret string of each staging label.
+type fakeSecretsManager struct {
+	mu       sync.Mutex
+	versions map[string]string
+	calls    []string
+}
+
+func newFakeSecretsManager(t *testing.T, versions map[string]string) (*fakeSecretsManager, *httptest.Server) {
+	t.Helper()
+
+	f := &fakeSecretsManager{versions: versions}
+	ts := httptest.NewServer(f)
+	t.Cleanup(ts.Close)
+
+	return f, ts
+}
+
+func (f *fakeSecretsManager) set(stage, secret string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if secret == "" {
+		delete(f.versions, stage)
+	} else {
+		f.versions[stage] = secret
+	}
+}
+
+func (f *fakeSecretsManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+
+	if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"__type": "InvalidRequestException", "message": "unsupported action"}`)
+
+		return
+	}
+
+	var in struct {
+		SecretID     string `json:"SecretId"`
+		VersionStage string `json:"VersionStage"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	f.mu.Lock()
+	f.calls = append(f.calls, in.VersionStage)
+	secret, ok := f.versions[in.VersionStage]
+	f.mu.Unlock()
+
+	if in.SecretID != secretID || !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, `{"__type": "ResourceNotFoundException", "message": "no version with staging label %s"}`,
+			in.VersionStage)
+
+		return
+	}
+
+	//nolint:errchkjson
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"ARN":           "arn:aws:secretsmanager:us-east-1:123456789012:secret:" + secretID,
+		"Name":          secretID,
+		"SecretString":  secret,
+		"VersionId":     in.VersionStage + "-version",
+		"VersionStages": []string{in.VersionStage},
+	})
+}
+
+func secretJSON(password string) string {
+	return fmt.Sprintf(`{"engine": "postgres", "username": "%s", "password": "%s"}`, username, password)
+}
+
+func newTestStore(t *testing.T, ts *httptest.Server) *Store {
+	t.Helper()
+
+	s, err := NewStore(&Config{
+		Client: secretsmanager.New(secretsmanager.Options{
+			Region:       "us-east-1",
+			Credentials:  aws.AnonymousCredentials{},
+			BaseEndpoint: aws.String(ts.URL),
+		}),
+		SecretID: secretID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func expectPassword(t *testing.T, creds driver.Credentials, err error, expected string) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if creds.GetUsername() != username || creds.GetPassword() != expected {
+		t.Fatalf("expected '%s' but got '%s' instead", expected, creds.GetPassword())
+	}
+}
+
+func TestStoreValidation(t *testing.T) {
+	testCases := []struct {
+		description string
+		cfg         *Config
+		expectedErr error
+	}{
+		{
+			description: "missing config",
+			expectedErr: errMissingConfig,
+		},
+		{
+			description: "missing client",
+			cfg:         &Config{SecretID: secretID},
+			expectedErr: errMissingClient,
+		},
+		{
+			description: "missing secret ID",
+			cfg:         &Config{Client: &secretsmanager.Client{}},
+			expectedErr: errMissingSecretID,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			if _, err := NewStore(tc.cfg); !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected '%v' but got '%v' instead", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestStoreGetCachesCurrentVersion(t *testing.T) {
+	f, ts := newFakeSecretsManager(t, map[string]string{StageCurrent: secretJSON("current")})
+	s := newTestStore(t, ts)
+
+	for range 2 {
+		creds, err := s.Get(context.Background())
+		expectPassword(t, creds, err, "current")
+	}
+
+	if len(f.calls) != 1 {
+		t.Fatalf("expected the secret to be read once but it was read %d times", len(f.calls))
+	}
+}
+
+func TestStoreRefreshFollowsRotation(t *testing.T) {
+	f, ts := newFakeSecretsManager(t, map[string]string{StageCurrent: secretJSON("v1")})
+	s := newTestStore(t, ts)
+
+	creds, err := s.Get(context.Background())
+	expectPassword(t, creds, err, "v1")
+
+	// The rotation function has set the new password in the database but not promoted it yet, so the
+	// database rejects v1 and the pending version has to be used.
+	f.set(StagePending, secretJSON("v2"))
+
+	creds, err = s.Refresh(context.Background())
+	expectPassword(t, creds, err, "v2")
+
+	// Once the rotation finishes v2 is current and there is no pending version.
+	f.set(StageCurrent, secretJSON("v2"))
+	f.set(StagePending, "")
+
+	creds, err = s.Refresh(context.Background())
+	expectPassword(t, creds, err, "v2")
+
+	// A rotation that finishes between refreshes is picked up from the current version.
+	f.set(StageCurrent, secretJSON("v3"))
+
+	creds, err = s.Refresh(context.Background())
+	expectPassword(t, creds, err, "v3")
+
+	if creds, err = s.Get(context.Background()); err != nil || creds.GetPassword() != "v3" {
+		t.Fatalf("expected '%s' to be cached but got '%v' instead", "v3", creds)
+	}
+}
+
+func TestStoreReturnsErrors(t *testing.T) {
+	testCases := []struct {
+		description string
+		versions    map[string]string
+	}{
+		{
+			description: "missing secret",
+			versions:    map[string]string{},
+		},
+		{
+			description: "unmappable secret",
+			versions:    map[string]string{StageCurrent: `{"user": "app"}`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			_, ts := newFakeSecretsManager(t, tc.versions)
+			s := newTestStore(t, ts)
+
+			if _, err := s.Get(context.Background()); err == nil {
+				t.Fatal("expected an error but got nil")
+			}
+
+			if _, err := s.Refresh(context.Background()); err == nil {
+				t.Fatal("expected an error but got nil")
+			}
+		})
+	}
+}
+
+func TestStoreUsesMapper(t *testing.T) {
+	_, ts := newFakeSecretsManager(t, map[string]string{StageCurrent: `{"user": "app", "pass": "secret"}`})
+
+	s := newTestStore(t, ts)
+	s.Mapper = store.JSONFieldMapper("user", "pass")
+
+	creds, err := s.Get(context.Background())
+	expectPassword(t, creds, err, "secret")
+}
@@ -0,0 +1,325 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+)
+
+// Default values of BreakerConfig.
+const (
+	DefaultFailureThreshold = 5
+	DefaultOpenTimeout      = 30 * time.Second
+)
+
+// ErrBreakerOpen is returned by Breaker.Refresh instead of calling the store while the breaker is
+// open. It wraps the error that opened the breaker.
+var ErrBreakerOpen = errors.New("store circuit breaker is open")
+
+// BreakerState is the state of a Breaker.
+type BreakerState int
+
+const (
+	// BreakerClosed passes calls to Refresh through to the store.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen fails calls to Refresh with ErrBreakerOpen without calling the store.
+	BreakerOpen
+	// BreakerHalfOpen lets a single call to Refresh through to probe whether the store has recovered.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("BreakerState(%d)", int(s))
+	}
+}
+
+// BreakerConfig contains configuration information for a Breaker.
+type BreakerConfig struct {
+	// FailureThreshold is how many consecutive calls to Refresh have to fail to open the breaker.
+	// It defaults to DefaultFailureThreshold.
+	FailureThreshold int
+	// OpenTimeout is how long the breaker stays open before letting a call through to probe the
+	// store. It defaults to DefaultOpenTimeout.
+	OpenTimeout time.Duration
+	// MinRefreshInterval is the minimum time between calls to Refresh on the store. Calls made
+	// sooner return the result of the last call. It is optional.
+	MinRefreshInterval time.Duration
+	// OnStateChange is called when the breaker changes state. It is called without the breaker's
+	// lock held so it can call State. It is optional.
+	OnStateChange func(from, to BreakerState)
+	// Logger logs when the breaker changes state. It is optional.
+	Logger *slog.Logger
+}
+
+// Breaker is a Store that stops calling Store.Refresh after it fails repeatedly, so that when the
+// database rejects credentials in a tight loop every connection attempt doesn't hit a store that is
+// already struggling, like Vault during an outage. Calls to Get are passed through.
+type Breaker struct {
+	store              driver.Store
+	failureThreshold   int
+	openTimeout        time.Duration
+	minRefreshInterval time.Duration
+	onStateChange      func(from, to BreakerState)
+	logger             *slog.Logger
+	now                func() time.Time
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+	// lastRefresh is when the store was last refreshed and lastCreds and lastErr are the result.
+	lastRefresh time.Time
+	lastCreds   driver.Credentials
+	lastErr     error
+	// inFlight is closed when the call to the store started at lastRefresh returns.
+	inFlight chan struct{}
+	// changes are the state changes to report once mu is released.
+	changes []stateChange
+}
+
+// stateChange is a change of state of a Breaker and the failures that caused it.
+type stateChange struct {
+	from, to BreakerState
+	failures int
+}
+
+var (
+	_ driver.Store    = (*Breaker)(nil)
+	_ driver.Revoker  = (*Breaker)(nil)
+	_ driver.Notifier = (*Breaker)(nil)
+	_ io.Closer       = (*Breaker)(nil)
+)
+
+// NewBreaker wraps s in a Breaker. cfg is optional.
+func NewBreaker(s driver.Store, cfg *BreakerConfig) *Breaker {
+	b := &Breaker{
+		store:            s,
+		failureThreshold: DefaultFailureThreshold,
+		openTimeout:      DefaultOpenTimeout,
+		now:              time.Now,
+	}
+
+	if cfg != nil {
+		if cfg.FailureThreshold > 0 {
+			b.failureThreshold = cfg.FailureThreshold
+		}
+
+		if cfg.OpenTimeout > 0 {
+			b.openTimeout = cfg.OpenTimeout
+		}
+
+		b.minRefreshInterval = cfg.MinRefreshInterval
+		b.onStateChange = cfg.OnStateChange
+		b.logger = cfg.Logger
+	}
+
+	return b
+}
+
+// Get implements the Store interface.
+func (b *Breaker) Get(ctx context.Context) (driver.Credentials, error) {
+	return b.store.Get(ctx)
+}
+
+// Refresh implements the Store interface. A call canceled by its caller isn't counted as a failure of
+// the store.
+func (b *Breaker) Refresh(ctx context.Context) (driver.Credentials, error) {
+	for {
+		done, inFlight, cached := b.before()
+		if inFlight != nil {
+			// Wait for the call in progress to record its result and then decide again.
+			select {
+			case <-inFlight:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		if done == nil {
+			return cached.creds, cached.err
+		}
+
+		creds, err := b.store.Refresh(ctx)
+		b.after(done, creds, err)
+
+		return creds, err
+	}
+}
+
+// State returns the state of the breaker.
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && !b.now().Before(b.openedAt.Add(b.openTimeout)) {
+		return BreakerHalfOpen
+	}
+
+	return b.state
+}
+
+// Unwrap returns the wrapped store.
+func (b *Breaker) Unwrap() driver.Store {
+	return b.store
+}
+
+// Revoke implements the driver.Revoker interface if the wrapped store does.
+func (b *Breaker) Revoke(ctx context.Context) error {
+	if r, ok := b.store.(driver.Revoker); ok {
+		return r.Revoke(ctx)
+	}
+
+	return nil
+}
+
+// Close implements io.Closer if the wrapped store does.
+func (b *Breaker) Close() error {
+	if closer, ok := b.store.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// Notify implements the driver.Notifier interface if the wrapped store does.
+func (b *Breaker) Notify(fn func(driver.Credentials)) func() {
+	if n, ok := b.store.(driver.Notifier); ok {
+		return n.Notify(fn)
+	}
+
+	return func() {}
+}
+
+// refreshResult is what a call to Refresh returns.
+type refreshResult struct {
+	creds driver.Credentials
+	err   error
+}
+
+// before decides whether a call to Refresh goes through to the store. When it does, it returns a
+// channel for after to close once the call returns. When a call made less than MinRefreshInterval
+// ago is still in progress, it returns that call's channel to wait on instead. Otherwise it returns
+// the result to return without calling the store.
+func (b *Breaker) before() (chan struct{}, <-chan struct{}, refreshResult) {
+	b.mu.Lock()
+	defer b.unlock()
+
+	now := b.now()
+
+	switch b.state {
+	case BreakerOpen:
+		if now.Before(b.openedAt.Add(b.openTimeout)) {
+			return nil, nil, refreshResult{err: fmt.Errorf("%w: %w", ErrBreakerOpen, b.lastErr)}
+		}
+
+		b.setState(BreakerHalfOpen)
+	case BreakerHalfOpen:
+		if b.probing {
+			return nil, nil, refreshResult{err: fmt.Errorf("%w: %w", ErrBreakerOpen, b.lastErr)}
+		}
+	case BreakerClosed:
+		if b.minRefreshInterval > 0 && !b.lastRefresh.IsZero() && now.Sub(b.lastRefresh) < b.minRefreshInterval {
+			if b.inFlight != nil {
+				return nil, b.inFlight, refreshResult{}
+			}
+
+			return nil, nil, refreshResult{creds: b.lastCreds, err: b.lastErr}
+		}
+	}
+
+	b.probing = b.state == BreakerHalfOpen
+	b.lastRefresh = now
+	b.inFlight = make(chan struct{})
+
+	return b.inFlight, nil, refreshResult{}
+}
+
+// after records the result of a call to Refresh and then closes done to wake the calls waiting for
+// it.
+func (b *Breaker) after(done chan struct{}, creds driver.Credentials, err error) {
+	b.mu.Lock()
+	defer b.unlock()
+	defer close(done)
+
+	b.probing = false
+
+	if b.inFlight == done {
+		b.inFlight = nil
+	}
+
+	// The store didn't get to answer so the next call goes through to it, probing it again if the
+	// breaker is half-open.
+	if errors.Is(err, context.Canceled) {
+		b.lastRefresh = time.Time{}
+
+		return
+	}
+
+	b.lastCreds, b.lastErr = creds, err
+
+	if err == nil {
+		b.failures = 0
+		b.setState(BreakerClosed)
+
+		return
+	}
+
+	b.failures++
+
+	if b.state == BreakerHalfOpen || b.failures >= b.failureThreshold {
+		b.openedAt = b.now()
+		b.setState(BreakerOpen)
+	}
+}
+
+// setState changes the state of the breaker. The change is reported by unlock. It must be called with
+// b.mu held.
+func (b *Breaker) setState(state BreakerState) {
+	from := b.state
+	if from == state {
+		return
+	}
+
+	b.state = state
+	b.changes = append(b.changes, stateChange{from: from, to: state, failures: b.failures})
+}
+
+// unlock releases b.mu and then logs the state changes made while it was held and passes them to
+// OnStateChange, so neither is called with the lock held.
+func (b *Breaker) unlock() {
+	changes := b.changes
+	b.changes = nil
+	b.mu.Unlock()
+
+	for _, c := range changes {
+		if b.logger != nil {
+			level := slog.LevelInfo
+			if c.to == BreakerOpen {
+				level = slog.LevelWarn
+			}
+
+			b.logger.Log(context.Background(), level, "store circuit breaker changed state",
+				"from", c.from, "to", c.to, "failures", c.failures)
+		}
+
+		if b.onStateChange != nil {
+			b.onStateChange(c.from, c.to)
+		}
+	}
+}
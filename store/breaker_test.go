@@ -0,0 +1,331 @@
+package store
+
+import (
+	"context"
+	sqldriver "database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+)
+
+// countingStore counts calls to Refresh, which fail with refreshErr when it is set.
+type countingStore struct {
+	flakyStore
+	refreshErr error
+	refreshes  int
+}
+
+func (s *countingStore) Refresh(ctx context.Context) (driver.Credentials, error) {
+	s.refreshes++
+
+	if s.refreshErr != nil {
+		return nil, s.refreshErr
+	}
+
+	return s.flakyStore.Refresh(ctx)
+}
+
+// testClock is a clock tests move by hand.
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func newTestBreaker(s driver.Store, cfg *BreakerConfig) (*Breaker, *testClock) {
+	clock := &testClock{now: time.Now()}
+	b := NewBreaker(s, cfg)
+	b.now = clock.Now
+
+	return b, clock
+}
+
+func TestBreakerOpensAndProbes(t *testing.T) {
+	s := &countingStore{flakyStore: flakyStore{creds: &Credential{Username: "foo", Password: "bar"}}, refreshErr: errTestStore}
+
+	var (
+		b           *Breaker
+		clock       *testClock
+		transitions []BreakerState
+	)
+
+	b, clock = newTestBreaker(s, &BreakerConfig{
+		FailureThreshold: 2,
+		OpenTimeout:      time.Minute,
+		// OnStateChange is called without the breaker's lock held so it can call back into it.
+		OnStateChange: func(_, to BreakerState) {
+			if state := b.State(); state != to {
+				t.Errorf("expected '%v' but got '%v' instead", to, state)
+			}
+
+			transitions = append(transitions, to)
+		},
+	})
+
+	for range 2 {
+		if _, err := b.Refresh(context.Background()); !errors.Is(err, errTestStore) || errors.Is(err, ErrBreakerOpen) {
+			t.Fatalf("expected '%v' but got '%v' instead", errTestStore, err)
+		}
+	}
+
+	if b.State() != BreakerOpen {
+		t.Fatalf("expected '%v' but got '%v' instead", BreakerOpen, b.State())
+	}
+
+	if _, err := b.Refresh(context.Background()); !errors.Is(err, ErrBreakerOpen) || !errors.Is(err, errTestStore) {
+		t.Fatalf("expected '%v' but got '%v' instead", ErrBreakerOpen, err)
+	}
+
+	if s.refreshes != 2 {
+		t.Fatalf("expected the store to be refreshed '%d' times but it was refreshed '%d' times", 2, s.refreshes)
+	}
+
+	// A failed probe opens the breaker again.
+	clock.now = clock.now.Add(time.Minute)
+
+	if b.State() != BreakerHalfOpen {
+		t.Fatalf("expected '%v' but got '%v' instead", BreakerHalfOpen, b.State())
+	}
+
+	if _, err := b.Refresh(context.Background()); !errors.Is(err, errTestStore) || errors.Is(err, ErrBreakerOpen) {
+		t.Fatalf("expected '%v' but got '%v' instead", errTestStore, err)
+	}
+
+	if _, err := b.Refresh(context.Background()); !errors.Is(err, ErrBreakerOpen) {
+		t.Fatalf("expected '%v' but got '%v' instead", ErrBreakerOpen, err)
+	}
+
+	// A successful probe closes it.
+	clock.now = clock.now.Add(time.Minute)
+	s.refreshErr = nil
+
+	if _, err := b.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []BreakerState{BreakerOpen, BreakerHalfOpen, BreakerOpen, BreakerHalfOpen, BreakerClosed}
+	if len(transitions) != len(expected) {
+		t.Fatalf("expected '%v' but got '%v' instead", expected, transitions)
+	}
+
+	for i := range expected {
+		if transitions[i] != expected[i] {
+			t.Fatalf("expected '%v' but got '%v' instead", expected, transitions)
+		}
+	}
+}
+
+func TestBreakerHalfOpenLetsOneProbeThrough(t *testing.T) {
+	s := &countingStore{refreshErr: errTestStore}
+	b, clock := newTestBreaker(s, &BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute})
+
+	if _, err := b.Refresh(context.Background()); !errors.Is(err, errTestStore) {
+		t.Fatalf("expected '%v' but got '%v' instead", errTestStore, err)
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+
+	// Simulate a probe in flight.
+	if done, _, _ := b.before(); done == nil {
+		t.Fatal("expected the probe to be let through")
+	}
+
+	if _, err := b.Refresh(context.Background()); !errors.Is(err, ErrBreakerOpen) {
+		t.Fatalf("expected '%v' but got '%v' instead", ErrBreakerOpen, err)
+	}
+}
+
+func TestBreakerDoesNotCountCanceledCalls(t *testing.T) {
+	s := &countingStore{
+		flakyStore: flakyStore{creds: &Credential{Username: "foo", Password: "bar"}},
+		refreshErr: context.Canceled,
+	}
+	b, clock := newTestBreaker(s, &BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute})
+
+	if _, err := b.Refresh(context.Background()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected '%v' but got '%v' instead", context.Canceled, err)
+	}
+
+	if b.State() != BreakerClosed {
+		t.Fatalf("expected '%v' but got '%v' instead", BreakerClosed, b.State())
+	}
+
+	s.refreshErr = errTestStore
+
+	if _, err := b.Refresh(context.Background()); !errors.Is(err, errTestStore) {
+		t.Fatalf("expected '%v' but got '%v' instead", errTestStore, err)
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+	s.refreshErr = context.Canceled
+
+	// A canceled probe leaves the breaker half-open so the next call probes the store again.
+	if _, err := b.Refresh(context.Background()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected '%v' but got '%v' instead", context.Canceled, err)
+	}
+
+	if b.State() != BreakerHalfOpen {
+		t.Fatalf("expected '%v' but got '%v' instead", BreakerHalfOpen, b.State())
+	}
+
+	s.refreshErr = nil
+
+	if _, err := b.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if b.State() != BreakerClosed {
+		t.Fatalf("expected '%v' but got '%v' instead", BreakerClosed, b.State())
+	}
+
+	if s.refreshes != 4 {
+		t.Fatalf("expected the store to be refreshed '%d' times but it was refreshed '%d' times", 4, s.refreshes)
+	}
+}
+
+func TestBreakerEnforcesMinRefreshInterval(t *testing.T) {
+	s := &countingStore{flakyStore: flakyStore{creds: &Credential{Username: "foo", Password: "bar"}}}
+	b, clock := newTestBreaker(s, &BreakerConfig{MinRefreshInterval: time.Second})
+
+	for range 3 {
+		creds, err := b.Refresh(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if creds.GetPassword() != "bar" {
+			t.Fatalf("expected '%s' but got '%s' instead", "bar", creds.GetPassword())
+		}
+	}
+
+	if s.refreshes != 1 {
+		t.Fatalf("expected the store to be refreshed '%d' times but it was refreshed '%d' times", 1, s.refreshes)
+	}
+
+	clock.now = clock.now.Add(time.Second)
+
+	if _, err := b.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.refreshes != 2 {
+		t.Fatalf("expected the store to be refreshed '%d' times but it was refreshed '%d' times", 2, s.refreshes)
+	}
+}
+
+// blockingStore is a store whose calls to Refresh block until release is closed. Every call is sent
+// to started first.
+type blockingStore struct {
+	flakyStore
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingStore) Refresh(ctx context.Context) (driver.Credentials, error) {
+	s.started <- struct{}{}
+	<-s.release
+
+	return s.flakyStore.Refresh(ctx)
+}
+
+func TestBreakerSharesRefreshInProgress(t *testing.T) {
+	s := &blockingStore{
+		flakyStore: flakyStore{creds: &Credential{Username: "foo", Password: "bar"}},
+		started:    make(chan struct{}, 2),
+		release:    make(chan struct{}),
+	}
+	b, _ := newTestBreaker(s, &BreakerConfig{MinRefreshInterval: time.Second})
+
+	results := make(chan driver.Credentials, 2)
+	refresh := func() {
+		creds, err := b.Refresh(context.Background())
+		if err != nil {
+			t.Error(err)
+		}
+
+		results <- creds
+	}
+
+	go refresh()
+	<-s.started
+
+	// A call made while the first one is in progress waits for its result instead of calling the
+	// store again or returning the result of an earlier call.
+	go refresh()
+
+	select {
+	case <-s.started:
+		t.Fatal("expected the store to be refreshed once")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(s.release)
+
+	for range 2 {
+		if creds := <-results; creds == nil || creds.GetPassword() != "bar" {
+			t.Fatalf("expected '%s' but got '%v' instead", "bar", creds)
+		}
+	}
+}
+
+// rejectingDriver rejects every connection as an authentication failure.
+type rejectingDriver struct{}
+
+var errRejected = errors.New("password authentication failed")
+
+func (rejectingDriver) Open(_ string) (sqldriver.Conn, error) {
+	return nil, errRejected
+}
+
+func TestConnectorFailsFastWhenBreakerIsOpen(t *testing.T) {
+	if err := driver.Register("breakertest", func() *driver.Driver {
+		return &driver.Driver{
+			Driver:    rejectingDriver{},
+			Formatter: driver.PgFormatter,
+			AuthError: func(err error) bool { return errors.Is(err, errRejected) },
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &countingStore{flakyStore: flakyStore{creds: &Credential{Username: "foo", Password: "bar"}}}
+	b := NewBreaker(s, &BreakerConfig{FailureThreshold: 2})
+
+	c, err := driver.NewConnector(b, "breakertest", &driver.Config{Host: "localhost", Port: 5432, DB: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.refreshErr = errTestStore
+
+	for range 2 {
+		if _, err := c.Connect(context.Background()); !errors.Is(err, errTestStore) {
+			t.Fatalf("expected '%v' but got '%v' instead", errTestStore, err)
+		}
+	}
+
+	if _, err := c.Connect(context.Background()); !errors.Is(err, ErrBreakerOpen) {
+		t.Fatalf("expected '%v' but got '%v' instead", ErrBreakerOpen, err)
+	}
+
+	if s.refreshes != 2 {
+		t.Fatalf("expected the store to be refreshed '%d' times but it was refreshed '%d' times", 2, s.refreshes)
+	}
+}
+
+func TestBreakerStateString(t *testing.T) {
+	for state, expected := range map[BreakerState]string{
+		BreakerClosed:    "closed",
+		BreakerOpen:      "open",
+		BreakerHalfOpen:  "half-open",
+		BreakerState(42): "BreakerState(42)",
+	} {
+		if state.String() != expected {
+			t.Fatalf("expected '%s' but got '%s' instead", expected, state.String())
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+)
+
+// ChainPolicy decides which store a ChainStore tries first.
+type ChainPolicy int
+
+const (
+	// PreferPrimary tries the stores in order on every call so the chain returns to the primary as
+	// soon as it recovers.
+	PreferPrimary ChainPolicy = iota
+	// StickyFallback keeps using the store that last served credentials and only moves on when it
+	// fails. The stores after it are tried first, then the ones before it.
+	StickyFallback
+)
+
+var errEmptyChain = errors.New("chain has no stores")
+
+// ChainConfig contains configuration information for a ChainStore.
+type ChainConfig struct {
+	// Policy decides which store is tried first. It defaults to PreferPrimary.
+	Policy ChainPolicy
+	// OnServe is called with the index of the store that served credentials and the errors of the
+	// stores tried before it, if any. It is optional.
+	OnServe func(ctx context.Context, index int, err error)
+}
+
+// ChainStore is a Store that gets credentials from the first of several stores that succeeds, e.g.
+// from the Vault API, falling back to a file rendered by Vault Agent and then to break-glass static
+// credentials.
+type ChainStore struct {
+	stores  []driver.Store
+	policy  ChainPolicy
+	onServe func(ctx context.Context, index int, err error)
+
+	mu      sync.Mutex
+	current int
+}
+
+var (
+	_ driver.Store    = (*ChainStore)(nil)
+	_ driver.Revoker  = (*ChainStore)(nil)
+	_ driver.Notifier = (*ChainStore)(nil)
+	_ io.Closer       = (*ChainStore)(nil)
+)
+
+// Chain creates a ChainStore with the PreferPrimary policy.
+func Chain(stores ...driver.Store) *ChainStore {
+	return NewChain(nil, stores...)
+}
+
+// NewChain creates a ChainStore. cfg is optional.
+func NewChain(cfg *ChainConfig, stores ...driver.Store) *ChainStore {
+	c := &ChainStore{
+		stores:  stores,
+		current: -1,
+	}
+
+	if cfg != nil {
+		c.policy = cfg.Policy
+		c.onServe = cfg.OnServe
+	}
+
+	return c
+}
+
+// Get implements the Store interface.
+func (c *ChainStore) Get(ctx context.Context) (driver.Credentials, error) {
+	return c.try(ctx, driver.Store.Get)
+}
+
+// Refresh implements the Store interface.
+func (c *ChainStore) Refresh(ctx context.Context) (driver.Credentials, error) {
+	return c.try(ctx, driver.Store.Refresh)
+}
+
+// Current returns the index of the store that last served credentials, or -1 if none has.
+func (c *ChainStore) Current() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.current
+}
+
+// Revoke implements the driver.Revoker interface. It revokes the credentials of every store that
+// implements driver.Revoker.
+func (c *ChainStore) Revoke(ctx context.Context) error {
+	var errs []error
+
+	for _, s := range c.stores {
+		if r, ok := s.(driver.Revoker); ok {
+			errs = append(errs, r.Revoke(ctx))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Close implements io.Closer. It closes every store that implements io.Closer.
+func (c *ChainStore) Close() error {
+	var errs []error
+
+	for _, s := range c.stores {
+		if closer, ok := s.(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Notify implements the driver.Notifier interface. Credentials sent by a store that implements
+// driver.Notifier are passed on while it is the store serving credentials.
+func (c *ChainStore) Notify(fn func(driver.Credentials)) func() {
+	var stops []func()
+
+	for i, s := range c.stores {
+		n, ok := s.(driver.Notifier)
+		if !ok {
+			continue
+		}
+
+		stops = append(stops, n.Notify(func(creds driver.Credentials) {
+			if c.Current() == i {
+				fn(creds)
+			}
+		}))
+	}
+
+	return func() {
+		for _, stop := range stops {
+			stop()
+		}
+	}
+}
+
+// try calls fn on each store in the order given by the policy until one succeeds.
+func (c *ChainStore) try(
+	ctx context.Context,
+	fn func(driver.Store, context.Context) (driver.Credentials, error),
+) (driver.Credentials, error) {
+	if len(c.stores) == 0 {
+		return nil, errEmptyChain
+	}
+
+	start := 0
+	if c.policy == StickyFallback {
+		start = max(c.Current(), 0)
+	}
+
+	var errs []error
+
+	for n := range len(c.stores) {
+		i := (start + n) % len(c.stores)
+
+		// Credentials the Connector would reject fall back to the next store like errors do.
+		creds, err := fn(c.stores[i], ctx)
+		if err == nil {
+			err = driver.ValidateCredentials(creds)
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("store %d: %w", i, err))
+
+			continue
+		}
+
+		c.mu.Lock()
+		c.current = i
+		c.mu.Unlock()
+
+		if c.onServe != nil {
+			c.onServe(ctx, i, errors.Join(errs...))
+		}
+
+		return creds, nil
+	}
+
+	return nil, errors.Join(errs...)
+}
@@ -0,0 +1,233 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+)
+
+var errTestStore = errors.New("store is down")
+
+// chainTestStore serves its credentials unless failing is set.
+type chainTestStore struct {
+	creds   *Credential
+	failing bool
+	calls   int
+	revoked bool
+	closed  bool
+	notify  func(driver.Credentials)
+}
+
+func (s *chainTestStore) Get(_ context.Context) (driver.Credentials, error) {
+	s.calls++
+
+	if s.failing {
+		return nil, errTestStore
+	}
+
+	return s.creds, nil
+}
+
+func (s *chainTestStore) Refresh(ctx context.Context) (driver.Credentials, error) {
+	return s.Get(ctx)
+}
+
+func (s *chainTestStore) Revoke(_ context.Context) error {
+	s.revoked = true
+
+	return nil
+}
+
+func (s *chainTestStore) Close() error {
+	s.closed = true
+
+	return nil
+}
+
+func (s *chainTestStore) Notify(fn func(driver.Credentials)) func() {
+	s.notify = fn
+
+	return func() {
+		s.notify = nil
+	}
+}
+
+func newChainTestStores() (*chainTestStore, *chainTestStore) {
+	return &chainTestStore{creds: &Credential{Username: "primary", Password: "bar"}},
+		&chainTestStore{creds: &Credential{Username: "secondary", Password: "bar"}}
+}
+
+func TestChainPolicies(t *testing.T) {
+	testCases := []struct {
+		description string
+		policy      ChainPolicy
+		// expected is the store expected to serve credentials once the primary has recovered.
+		expected string
+	}{
+		{
+			description: "prefer primary",
+			policy:      PreferPrimary,
+			expected:    "primary",
+		},
+		{
+			description: "sticky fallback",
+			policy:      StickyFallback,
+			expected:    "secondary",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			primary, secondary := newChainTestStores()
+
+			var served []int
+
+			c := NewChain(&ChainConfig{
+				Policy: tc.policy,
+				OnServe: func(_ context.Context, index int, _ error) {
+					served = append(served, index)
+				},
+			}, primary, secondary)
+
+			if c.Current() != -1 {
+				t.Fatalf("expected '%d' but got '%d' instead", -1, c.Current())
+			}
+
+			primary.failing = true
+
+			creds, err := c.Refresh(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if creds.GetUsername() != "secondary" || c.Current() != 1 {
+				t.Fatalf("expected '%s' but got '%s' instead", "secondary", creds.GetUsername())
+			}
+
+			primary.failing = false
+
+			if creds, err = c.Refresh(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			if creds.GetUsername() != tc.expected {
+				t.Fatalf("expected '%s' but got '%s' instead", tc.expected, creds.GetUsername())
+			}
+
+			if len(served) != 2 {
+				t.Fatalf("expected '%d' but got '%d' instead", 2, len(served))
+			}
+		})
+	}
+}
+
+func TestStickyFallbackWrapsAround(t *testing.T) {
+	primary, secondary := newChainTestStores()
+	c := NewChain(&ChainConfig{Policy: StickyFallback}, primary, secondary)
+
+	primary.failing = true
+
+	if _, err := c.Get(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	primary.failing = false
+	secondary.failing = true
+
+	creds, err := c.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if creds.GetUsername() != "primary" || c.Current() != 0 {
+		t.Fatalf("expected '%s' but got '%s' instead", "primary", creds.GetUsername())
+	}
+}
+
+func TestChainFallsBackOnInvalidCredentials(t *testing.T) {
+	primary, secondary := newChainTestStores()
+	primary.creds = &Credential{Username: "primary"}
+
+	var fallbackErr error
+
+	c := NewChain(&ChainConfig{
+		OnServe: func(_ context.Context, _ int, err error) {
+			fallbackErr = err
+		},
+	}, primary, secondary)
+
+	creds, err := c.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if creds.GetUsername() != "secondary" {
+		t.Fatalf("expected '%s' but got '%s' instead", "secondary", creds.GetUsername())
+	}
+
+	if !errors.Is(fallbackErr, driver.ErrMissingPassword) {
+		t.Fatalf("expected '%v' but got '%v' instead", driver.ErrMissingPassword, fallbackErr)
+	}
+}
+
+func TestChainReturnsEveryErrorWhenAllStoresFail(t *testing.T) {
+	primary, secondary := newChainTestStores()
+	primary.failing = true
+	secondary.failing = true
+
+	_, err := Chain(primary, secondary).Get(context.Background())
+	if !errors.Is(err, errTestStore) {
+		t.Fatalf("expected '%v' but got '%v' instead", errTestStore, err)
+	}
+
+	if primary.calls != 1 || secondary.calls != 1 {
+		t.Fatalf("expected each store to be called once but got '%d' and '%d'", primary.calls, secondary.calls)
+	}
+
+	if _, err := Chain().Get(context.Background()); !errors.Is(err, errEmptyChain) {
+		t.Fatalf("expected '%v' but got '%v' instead", errEmptyChain, err)
+	}
+}
+
+func TestChainPassesThroughRevokeCloseAndNotify(t *testing.T) {
+	primary, secondary := newChainTestStores()
+	c := Chain(primary, secondary, NewStatic("static", "bar"))
+
+	var notified []string
+
+	stop := c.Notify(func(creds driver.Credentials) {
+		notified = append(notified, creds.GetUsername())
+	})
+
+	if _, err := c.Get(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the store serving credentials is listened to.
+	primary.notify(&Credential{Username: "primary", Password: "baz"})
+	secondary.notify(&Credential{Username: "secondary", Password: "baz"})
+
+	if len(notified) != 1 || notified[0] != "primary" {
+		t.Fatalf("expected '%v' but got '%v' instead", []string{"primary"}, notified)
+	}
+
+	stop()
+
+	if primary.notify != nil || secondary.notify != nil {
+		t.Fatal("expected the chain to unsubscribe from every store")
+	}
+
+	if err := c.Revoke(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !primary.revoked || !secondary.revoked || !primary.closed || !secondary.closed {
+		t.Fatal("expected every store to be revoked and closed")
+	}
+}
@@ -0,0 +1,118 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+)
+
+// EnvStoreName is the name the Env store is registered with.
+const EnvStoreName = "env"
+
+// The variables the Env store reads when EnvConfig doesn't name any.
+const (
+	DefaultUsernameVar = "DB_USERNAME"
+	DefaultPasswordVar = "DB_PASSWORD"
+)
+
+type errEnvNotSet struct {
+	name string
+}
+
+func (e errEnvNotSet) Error() string {
+	return fmt.Sprintf("environment variable %s is not set", e.name)
+}
+
+// EnvConfig names the environment variables the Env store reads.
+type EnvConfig struct {
+	// UsernameVar holds the username. It defaults to DefaultUsernameVar.
+	UsernameVar string
+	// PasswordVar holds the password. It defaults to DefaultPasswordVar.
+	PasswordVar string
+}
+
+// Env is a Store that reads credentials from environment variables. They are read again on
+// Refresh so a process that updates its own environment, or a test, can rotate them.
+type Env struct {
+	usernameVar string
+	passwordVar string
+
+	mu    sync.Mutex
+	creds *Credential
+}
+
+// NewEnv creates an Env store. cfg is optional.
+func NewEnv(cfg *EnvConfig) *Env {
+	e := &Env{
+		usernameVar: DefaultUsernameVar,
+		passwordVar: DefaultPasswordVar,
+	}
+
+	if cfg != nil {
+		if cfg.UsernameVar != "" {
+			e.usernameVar = cfg.UsernameVar
+		}
+
+		if cfg.PasswordVar != "" {
+			e.passwordVar = cfg.PasswordVar
+		}
+	}
+
+	return e
+}
+
+// Get implements the Store interface.
+func (e *Env) Get(ctx context.Context) (driver.Credentials, error) {
+	e.mu.Lock()
+	creds := e.creds
+	e.mu.Unlock()
+
+	if creds != nil {
+		return creds, nil
+	}
+
+	return e.Refresh(ctx)
+}
+
+// Refresh implements the Store interface.
+func (e *Env) Refresh(_ context.Context) (driver.Credentials, error) {
+	username, ok := os.LookupEnv(e.usernameVar)
+	if !ok {
+		return nil, errEnvNotSet{e.usernameVar}
+	}
+
+	password, ok := os.LookupEnv(e.passwordVar)
+	if !ok {
+		return nil, errEnvNotSet{e.passwordVar}
+	}
+
+	creds := &Credential{
+		Username: username,
+		Password: password,
+	}
+
+	e.mu.Lock()
+	e.creds = creds
+	e.mu.Unlock()
+
+	return creds, nil
+}
+
+// NewEnvFromURL creates an Env store from a URL like env://?username_var=PGUSER&password_var=PGPASSWORD.
+// Both parameters are optional.
+func NewEnvFromURL(_ context.Context, u *url.URL) (driver.Store, error) {
+	q := u.Query()
+
+	if err := CheckParams(EnvStoreName, q, "username_var", "password_var"); err != nil {
+		return nil, err
+	}
+
+	return NewEnv(&EnvConfig{
+		UsernameVar: q.Get("username_var"),
+		PasswordVar: q.Get("password_var"),
+	}), nil
+}
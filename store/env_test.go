@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+	"net/url"
+	"testing"
+)
+
+func TestEnvRereadsVariablesOnRefresh(t *testing.T) {
+	t.Setenv("TEST_DB_USER", "foo")
+	t.Setenv("TEST_DB_PASS", "bar")
+
+	s, err := NewEnvFromURL(context.Background(), &url.URL{
+		Scheme:   EnvStoreName,
+		RawQuery: "username_var=TEST_DB_USER&password_var=TEST_DB_PASS",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	creds, err := s.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if creds.GetUsername() != "foo" || creds.GetPassword() != "bar" {
+		t.Fatalf("expected '%s' but got '%v' instead", "foo", creds)
+	}
+
+	t.Setenv("TEST_DB_PASS", "baz")
+
+	if creds, err = s.Get(context.Background()); err != nil || creds.GetPassword() != "bar" {
+		t.Fatalf("expected '%s' but got '%v' instead", "bar", creds)
+	}
+
+	if creds, err = s.Refresh(context.Background()); err != nil || creds.GetPassword() != "baz" {
+		t.Fatalf("expected '%s' but got '%v' instead", "baz", creds)
+	}
+}
+
+func TestEnvDefaultsAndMissingVariables(t *testing.T) {
+	t.Setenv(DefaultUsernameVar, "foo")
+
+	s := NewEnv(nil)
+
+	expected := errEnvNotSet{DefaultPasswordVar}
+	if _, err := s.Get(context.Background()); err != expected { //nolint:errorlint
+		t.Fatalf("expected '%v' but got '%v' instead", expected, err)
+	}
+
+	t.Setenv(DefaultPasswordVar, "bar")
+
+	creds, err := s.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if creds.GetUsername() != "foo" || creds.GetPassword() != "bar" {
+		t.Fatalf("expected '%s' but got '%v' instead", "foo", creds)
+	}
+}
@@ -0,0 +1,138 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+)
+
+// FileStoreName is the name the File store is registered with.
+const FileStoreName = "file"
+
+var (
+	errMissingUsername = errors.New("username field not set in credential string")
+	errMissingPassword = errors.New("password field not set in credential string")
+	// ErrNoCredentials is returned when a Mapper returns neither credentials nor an error.
+	ErrNoCredentials = errors.New("mapper returned no credentials")
+)
+
+// Mapper maps the contents of a credentials file to a Credential. This allows consumers to define
+// how their credential data is structured. It must return an error if it can't map them.
+type Mapper func(s string) (*Credential, error)
+
+// JSONMapper maps a JSON object with username and password fields, the structure the Vault database
+// secrets engine returns.
+func JSONMapper(s string) (*Credential, error) {
+	return JSONFieldMapper("username", "password")(s)
+}
+
+// JSONFieldMapper returns a Mapper for a JSON object holding the username and password in the given
+// fields.
+func JSONFieldMapper(usernameField, passwordField string) Mapper {
+	return func(s string) (*Credential, error) {
+		var v map[string]any
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			return nil, err
+		}
+
+		username, ok := v[usernameField].(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", errMissingUsername, usernameField)
+		}
+
+		password, ok := v[passwordField].(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", errMissingPassword, passwordField)
+		}
+
+		return &Credential{
+			Username: username,
+			Password: password,
+		}, nil
+	}
+}
+
+// File is a Store that reads credentials from a file, like one rendered by Vault Agent or mounted
+// from a Kubernetes secret. The file is read again on Refresh.
+type File struct {
+	path   string
+	mapper Mapper
+
+	mu    sync.Mutex
+	creds *Credential
+}
+
+// NewFile creates a File store reading path with mapper. A nil mapper defaults to JSONMapper.
+func NewFile(path string, mapper Mapper) *File {
+	if mapper == nil {
+		mapper = JSONMapper
+	}
+
+	return &File{
+		path:   path,
+		mapper: mapper,
+	}
+}
+
+// Get implements the Store interface.
+func (f *File) Get(ctx context.Context) (driver.Credentials, error) {
+	f.mu.Lock()
+	creds := f.creds
+	f.mu.Unlock()
+
+	if creds != nil {
+		return creds, nil
+	}
+
+	return f.Refresh(ctx)
+}
+
+// Refresh implements the Store interface.
+func (f *File) Refresh(_ context.Context) (driver.Credentials, error) {
+	b, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, err
+	}
+
+	creds, err := f.mapper(string(b))
+	if err != nil {
+		return nil, err
+	}
+
+	if creds == nil {
+		return nil, ErrNoCredentials
+	}
+
+	f.mu.Lock()
+	f.creds = creds
+	f.mu.Unlock()
+
+	return creds, nil
+}
+
+// NewFileFromURL creates a File store from a URL like file:///etc/db/credentials.json. The path can
+// also be given as the path parameter. The file is mapped with JSONMapper.
+func NewFileFromURL(_ context.Context, u *url.URL) (driver.Store, error) {
+	q := u.Query()
+
+	if err := CheckParams(FileStoreName, q, "path"); err != nil {
+		return nil, err
+	}
+
+	path := u.Path
+	if path == "" {
+		path = q.Get("path")
+	}
+
+	if path == "" {
+		return nil, &ParamError{Store: FileStoreName, Param: "path", Err: ErrMissingParam}
+	}
+
+	return NewFile(path, nil), nil
+}
@@ -0,0 +1,134 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileRereadsOnRefresh(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	writeFile(t, path, `{"username": "foo", "password": "bar"}`)
+
+	s, err := NewFileFromURL(context.Background(), &url.URL{Scheme: FileStoreName, Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	creds, err := s.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if creds.GetUsername() != "foo" || creds.GetPassword() != "bar" {
+		t.Fatalf("expected '%s' but got '%v' instead", "foo", creds)
+	}
+
+	writeFile(t, path, `{"username": "foo", "password": "baz"}`)
+
+	if creds, err = s.Get(context.Background()); err != nil || creds.GetPassword() != "bar" {
+		t.Fatalf("expected '%s' but got '%v' instead", "bar", creds)
+	}
+
+	if creds, err = s.Refresh(context.Background()); err != nil || creds.GetPassword() != "baz" {
+		t.Fatalf("expected '%s' but got '%v' instead", "baz", creds)
+	}
+}
+
+func TestFileUsesMapper(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials")
+	writeFile(t, path, "foo:bar\n")
+
+	s := NewFile(path, func(s string) (*Credential, error) {
+		username, password, _ := strings.Cut(strings.TrimSpace(s), ":")
+
+		return &Credential{Username: username, Password: password}, nil
+	})
+
+	creds, err := s.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if creds.GetUsername() != "foo" || creds.GetPassword() != "bar" {
+		t.Fatalf("expected '%s' but got '%v' instead", "foo", creds)
+	}
+}
+
+func TestFileErrorsIfMapperReturnsNoCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials")
+	writeFile(t, path, "foo:bar\n")
+
+	s := NewFile(path, func(string) (*Credential, error) {
+		return nil, nil //nolint:nilnil
+	})
+
+	if _, err := s.Get(context.Background()); !errors.Is(err, ErrNoCredentials) {
+		t.Fatalf("expected '%v' but got '%v' instead", ErrNoCredentials, err)
+	}
+}
+
+func TestJSONMapper(t *testing.T) {
+	testCases := []struct {
+		description string
+		input       string
+		expectedErr error
+	}{
+		{
+			description: "missing username",
+			input:       `{"user": "foo", "password": "bar"}`,
+			expectedErr: errMissingUsername,
+		},
+		{
+			description: "missing password",
+			input:       `{"username": "foo", "pass": "bar"}`,
+			expectedErr: errMissingPassword,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			if _, err := JSONMapper(tc.input); !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected '%v' but got '%v' instead", tc.expectedErr, err)
+			}
+		})
+	}
+
+	creds, err := JSONFieldMapper("user", "pass")(`{"user": "foo", "pass": "bar"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if creds.GetUsername() != "foo" || creds.GetPassword() != "bar" {
+		t.Fatalf("expected '%s' but got '%v' instead", "foo", creds)
+	}
+
+	if _, err := JSONMapper("not json"); err == nil {
+		t.Fatal("expected an error but got nil")
+	}
+}
+
+func TestNewFileFromURL(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := NewFileFromURL(ctx, &url.URL{Scheme: FileStoreName, RawQuery: "path=/tmp/credentials.json"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var paramErr *ParamError
+	if _, err := NewFileFromURL(ctx, &url.URL{Scheme: FileStoreName}); !errors.As(err, &paramErr) {
+		t.Fatalf("expected '%T' but got '%v' instead", paramErr, err)
+	}
+}
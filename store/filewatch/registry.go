@@ -0,0 +1,62 @@
+package filewatch
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+	"github.com/davepgreene/go-db-credential-refresh/store"
+)
+
+// StoreName is the name the watched file store is registered with in the store package.
+const StoreName = "filewatch"
+
+func init() { //nolint:gochecknoinits
+	if err := store.Register(StoreName, NewStoreFromURL); err != nil {
+		panic(err)
+	}
+}
+
+// NewStoreFromURL creates a Store from a URL like
+//
+//	filewatch:///vault/secrets/db.json?debounce=500ms
+//
+// It is registered with the store package so importing this package makes filewatch:// URIs
+// available to store.Open. The file is mapped with store.JSONMapper. The query accepts:
+//
+//   - path: the file holding the credentials when the URL has no path.
+//   - debounce: how long the file has to stop changing before it is read again.
+func NewStoreFromURL(_ context.Context, u *url.URL) (driver.Store, error) {
+	q := u.Query()
+
+	if err := store.CheckParams(StoreName, q, "path", "debounce"); err != nil {
+		return nil, err
+	}
+
+	path := u.Path
+	if path == "" {
+		path = q.Get("path")
+	}
+
+	if path == "" {
+		return nil, paramError("path", store.ErrMissingParam)
+	}
+
+	var debounce time.Duration
+	if v := q.Get("debounce"); v != "" {
+		var err error
+		if debounce, err = time.ParseDuration(v); err != nil {
+			return nil, paramError("debounce", err)
+		}
+	}
+
+	return NewStore(&Config{
+		Path:     path,
+		Debounce: debounce,
+	})
+}
+
+func paramError(param string, err error) error {
+	return &store.ParamError{Store: StoreName, Param: param, Err: err}
+}
@@ -0,0 +1,89 @@
+package filewatch
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+	"github.com/davepgreene/go-db-credential-refresh/store"
+)
+
+func TestStoreIsRegistered(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.json")
+	writeFile(t, path, credentialsJSON(password))
+
+	ctx := context.Background()
+
+	fromURI, err := store.Open(ctx, "filewatch://"+path+"?debounce=50ms")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromMap, err := store.OpenMap(ctx, StoreName, map[string]string{"path": path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []driver.Store{fromURI, fromMap} {
+		creds, err := s.Get(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if creds.GetUsername() != username || creds.GetPassword() != password {
+			t.Fatalf("expected '%s' but got '%s' instead", username, creds.GetUsername())
+		}
+
+		if err := s.(io.Closer).Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestNewStoreFromURLReportsInvalidParams(t *testing.T) {
+	testCases := []struct {
+		description string
+		uri         string
+		param       string
+		err         error
+	}{
+		{
+			description: "missing path",
+			uri:         "filewatch://",
+			param:       "path",
+		},
+		{
+			description: "invalid debounce",
+			uri:         "filewatch:///tmp/db.json?debounce=soon",
+			param:       "debounce",
+		},
+		{
+			description: "unknown parameter",
+			uri:         "filewatch:///tmp/db.json?debounc=1s",
+			param:       "debounc",
+			err:         store.ErrUnknownParam,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			_, err := store.Open(context.Background(), tc.uri)
+
+			var paramErr *store.ParamError
+			if !errors.As(err, &paramErr) {
+				t.Fatalf("expected '%T' but got '%v' instead", paramErr, err)
+			}
+
+			if paramErr.Param != tc.param {
+				t.Fatalf("expected '%s' but got '%s' instead", tc.param, paramErr.Param)
+			}
+
+			if tc.err != nil && !errors.Is(err, tc.err) {
+				t.Fatalf("expected '%v' but got '%v' instead", tc.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+// Package filewatch provides a Store that reads credentials from a file and watches it for changes,
+// like a file rendered by Vault Agent or a Kubernetes secret mounted as a volume. When the file is
+// rewritten the Store reads it again and sends the new credentials to the Connector so it switches to
+// them before the database rejects the old ones.
+package filewatch
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+	"github.com/davepgreene/go-db-credential-refresh/store"
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultDebounce is the default value of Config.Debounce.
+const DefaultDebounce = 100 * time.Millisecond
+
+// kubernetesDataDir is the symlink Kubernetes swaps atomically to update every file of a mounted
+// secret or config map at once. See https://pkg.go.dev/k8s.io/kubernetes/pkg/volume/util#AtomicWriter.
+const kubernetesDataDir = "..data"
+
+var (
+	errMissingConfig = errors.New("config is required")
+	errMissingPath   = errors.New("path is required")
+)
+
+// Config contains configuration information.
+type Config struct {
+	// Path is the file holding the credentials.
+	Path string
+	// Mapper maps the contents of the file to credentials. It defaults to store.JSONMapper.
+	Mapper store.Mapper
+	// Debounce is how long the file has to stop changing before it is read again, so a file written
+	// in several steps is only read once it is complete. It defaults to DefaultDebounce.
+	Debounce time.Duration
+	// Logger logs when the file changes and when its new contents can't be mapped. It is optional.
+	Logger *slog.Logger
+}
+
+// Store is a Store that reads credentials from a file and implements driver.Notifier to send them
+// to the Connector when the file changes. It has to be closed to stop watching the file, which the
+// Connector does when it is closed.
+type Store struct {
+	path     string
+	mapper   store.Mapper
+	debounce time.Duration
+	logger   *slog.Logger
+	watcher  *fsnotify.Watcher
+	done     chan struct{}
+	stopped  chan struct{}
+
+	mu          sync.Mutex
+	creds       *store.Credential
+	subscribers map[int]func(driver.Credentials)
+	nextID      int
+	closed      bool
+}
+
+var (
+	_ driver.Store    = (*Store)(nil)
+	_ driver.Notifier = (*Store)(nil)
+)
+
+// NewStore creates a Store and starts watching its file. The file doesn't have to exist yet, but
+// the directory it is in does.
+func NewStore(c *Config) (*Store, error) {
+	if c == nil {
+		return nil, errMissingConfig
+	}
+
+	if c.Path == "" {
+		return nil, errMissingPath
+	}
+
+	path, err := filepath.Abs(c.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	// The directory is watched rather than the file because files are usually replaced rather than
+	// written in place, which would end a watch on the file itself.
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		_ = watcher.Close()
+
+		return nil, err
+	}
+
+	s := &Store{
+		path:        path,
+		mapper:      c.Mapper,
+		debounce:    c.Debounce,
+		logger:      c.Logger,
+		watcher:     watcher,
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+		subscribers: make(map[int]func(driver.Credentials)),
+	}
+
+	if s.mapper == nil {
+		s.mapper = store.JSONMapper
+	}
+
+	if s.debounce <= 0 {
+		s.debounce = DefaultDebounce
+	}
+
+	go s.watch()
+
+	return s, nil
+}
+
+// Get implements the Store interface.
+func (s *Store) Get(ctx context.Context) (driver.Credentials, error) {
+	s.mu.Lock()
+	creds := s.creds
+	s.mu.Unlock()
+
+	if creds != nil {
+		return creds, nil
+	}
+
+	return s.Refresh(ctx)
+}
+
+// Refresh implements the Store interface.
+func (s *Store) Refresh(_ context.Context) (driver.Credentials, error) {
+	creds, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.creds = creds
+	s.mu.Unlock()
+
+	return creds, nil
+}
+
+// Notify implements the driver.Notifier interface.
+func (s *Store) Notify(fn func(driver.Credentials)) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.nextID
+	s.nextID++
+	s.subscribers[id] = fn
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		delete(s.subscribers, id)
+	}
+}
+
+// Close implements io.Closer. It stops watching the file.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+
+		return nil
+	}
+
+	s.closed = true
+	s.mu.Unlock()
+
+	close(s.done)
+	err := s.watcher.Close()
+	<-s.stopped
+
+	return err
+}
+
+func (s *Store) read() (*store.Credential, error) {
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	creds, err := s.mapper(string(b))
+	if err != nil {
+		return nil, err
+	}
+
+	if creds == nil {
+		return nil, store.ErrNoCredentials
+	}
+
+	return creds, nil
+}
+
+// watch waits for the file to change and reloads it once it has stopped changing for the debounce
+// period.
+func (s *Store) watch() {
+	defer close(s.stopped)
+
+	timer := time.NewTimer(s.debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case event, ok := <-s.watcher.Events:
+			if !ok {
+				return
+			}
+
+			if s.affects(event) {
+				timer.Reset(s.debounce)
+			}
+		case err, ok := <-s.watcher.Errors:
+			if !ok {
+				return
+			}
+
+			if s.logger != nil {
+				s.logger.Error("error watching credentials file", "path", s.path, "error", err)
+			}
+		case <-timer.C:
+			s.reload()
+		case <-s.done:
+			timer.Stop()
+
+			return
+		}
+	}
+}
+
+// affects reports whether event may have changed the contents of the file. Besides changes to the
+// file itself that includes Kubernetes swapping its data directory, which the file links into.
+func (s *Store) affects(event fsnotify.Event) bool {
+	if event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+		return false
+	}
+
+	name := filepath.Base(event.Name)
+
+	return name == filepath.Base(s.path) || name == kubernetesDataDir
+}
+
+// reload reads the file and sends the credentials in it to the subscribers if they've changed.
+// Contents that can't be read or mapped, like a partially written file, are ignored and the current
+// credentials are kept.
+func (s *Store) reload() {
+	creds, err := s.read()
+	if err != nil {
+		if s.logger != nil {
+			s.logger.Warn("ignoring unreadable credentials file", "path", s.path, "error", err)
+		}
+
+		return
+	}
+
+	s.mu.Lock()
+	if s.creds != nil && s.creds.Username == creds.Username && s.creds.Password == creds.Password {
+		s.mu.Unlock()
+
+		return
+	}
+
+	s.creds = creds
+
+	subscribers := make([]func(driver.Credentials), 0, len(s.subscribers))
+	for _, fn := range s.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	s.mu.Unlock()
+
+	if s.logger != nil {
+		s.logger.Info("credentials file changed", "path", s.path, "username", creds.Username)
+	}
+
+	for _, fn := range subscribers {
+		fn(creds)
+	}
+}
@@ -0,0 +1,254 @@
+package filewatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+	"github.com/davepgreene/go-db-credential-refresh/store"
+)
+
+const (
+	username = "foo"
+	password = "bar"
+
+	testDebounce = 20 * time.Millisecond
+	testTimeout  = 2 * time.Second
+)
+
+func credentialsJSON(password string) []byte {
+	return []byte(fmt.Sprintf(`{"username": "%s", "password": "%s"}`, username, password))
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestStore(t *testing.T, path string) (*Store, chan driver.Credentials) {
+	t.Helper()
+
+	s, err := NewStore(&Config{Path: path, Debounce: testDebounce})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := s.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	if _, err := s.Get(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	notified := make(chan driver.Credentials, 10)
+	s.Notify(func(creds driver.Credentials) {
+		notified <- creds
+	})
+
+	return s, notified
+}
+
+func expectNotification(t *testing.T, notified chan driver.Credentials, expected string) {
+	t.Helper()
+
+	select {
+	case creds := <-notified:
+		if creds.GetPassword() != expected {
+			t.Fatalf("expected '%s' but got '%s' instead", expected, creds.GetPassword())
+		}
+	case <-time.After(testTimeout):
+		t.Fatalf("expected to be sent '%s' but wasn't sent anything", expected)
+	}
+}
+
+func expectNoNotification(t *testing.T, notified chan driver.Credentials) {
+	t.Helper()
+
+	select {
+	case creds := <-notified:
+		t.Fatalf("expected no notification but got '%s'", creds.GetPassword())
+	case <-time.After(10 * testDebounce):
+	}
+}
+
+func TestNewStoreValidatesConfig(t *testing.T) {
+	if _, err := NewStore(nil); err != errMissingConfig { //nolint:errorlint
+		t.Fatalf("expected '%v' but got '%v' instead", errMissingConfig, err)
+	}
+
+	if _, err := NewStore(&Config{}); err != errMissingPath { //nolint:errorlint
+		t.Fatalf("expected '%v' but got '%v' instead", errMissingPath, err)
+	}
+
+	if _, err := NewStore(&Config{Path: filepath.Join(t.TempDir(), "missing", "db.json")}); err == nil {
+		t.Fatal("expected an error watching a missing directory but got nil")
+	}
+}
+
+func TestStoreNotifiesWhenFileIsRewritten(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.json")
+	writeFile(t, path, credentialsJSON(password))
+
+	s, notified := newTestStore(t, path)
+
+	writeFile(t, path, credentialsJSON("baz"))
+	expectNotification(t, notified, "baz")
+
+	// Replacing the file, like editors and Vault Agent do, is picked up too.
+	tmp := path + ".tmp"
+	writeFile(t, tmp, credentialsJSON("qux"))
+
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+
+	expectNotification(t, notified, "qux")
+
+	creds, err := s.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if creds.GetPassword() != "qux" {
+		t.Fatalf("expected '%s' but got '%s' instead", "qux", creds.GetPassword())
+	}
+}
+
+func TestStoreDebouncesAndIgnoresInvalidContents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.json")
+	writeFile(t, path, credentialsJSON(password))
+
+	s, notified := newTestStore(t, path)
+
+	// A partially written file is never mapped or sent.
+	writeFile(t, path, []byte(`{"username": "foo",`))
+	writeFile(t, path, credentialsJSON("baz"))
+	expectNotification(t, notified, "baz")
+	expectNoNotification(t, notified)
+
+	writeFile(t, path, []byte("not json"))
+	expectNoNotification(t, notified)
+
+	// Rewriting the same credentials doesn't send them again.
+	writeFile(t, path, credentialsJSON("baz"))
+	expectNoNotification(t, notified)
+
+	creds, err := s.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if creds.GetPassword() != "baz" {
+		t.Fatalf("expected '%s' but got '%s' instead", "baz", creds.GetPassword())
+	}
+}
+
+func TestStoreIgnoresContentsMappedToNoCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.json")
+	writeFile(t, path, credentialsJSON(password))
+
+	s, err := NewStore(&Config{
+		Path:     path,
+		Debounce: testDebounce,
+		Mapper: func(contents string) (*store.Credential, error) {
+			if contents == "" {
+				return nil, nil //nolint:nilnil
+			}
+
+			return store.JSONMapper(contents)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	notified := make(chan driver.Credentials, 10)
+	s.Notify(func(creds driver.Credentials) {
+		notified <- creds
+	})
+
+	writeFile(t, path, nil)
+	expectNoNotification(t, notified)
+
+	if _, err := s.Refresh(context.Background()); !errors.Is(err, store.ErrNoCredentials) {
+		t.Fatalf("expected '%v' but got '%v' instead", store.ErrNoCredentials, err)
+	}
+
+	writeFile(t, path, credentialsJSON("baz"))
+	expectNotification(t, notified, "baz")
+}
+
+// TestStoreNotifiesOnKubernetesAtomicUpdate lays files out the way the kubelet does for a mounted
+// secret: the file is a symlink into ..data, which is a symlink to a timestamped directory that gets
+// swapped by renaming a new symlink over ..data.
+func TestStoreNotifiesOnKubernetesAtomicUpdate(t *testing.T) {
+	dir := t.TempDir()
+
+	writeVersion := func(version, password string) {
+		if err := os.Mkdir(filepath.Join(dir, version), 0o700); err != nil {
+			t.Fatal(err)
+		}
+
+		writeFile(t, filepath.Join(dir, version, "db.json"), credentialsJSON(password))
+
+		tmp := filepath.Join(dir, "..data_tmp")
+		if err := os.Symlink(version, tmp); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.Rename(tmp, filepath.Join(dir, kubernetesDataDir)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeVersion("..2024_01_01_00_00_00.1", password)
+
+	path := filepath.Join(dir, "db.json")
+	if err := os.Symlink(filepath.Join(kubernetesDataDir, "db.json"), path); err != nil {
+		t.Fatal(err)
+	}
+
+	_, notified := newTestStore(t, path)
+
+	writeVersion("..2024_01_01_00_01_00.2", "baz")
+	expectNotification(t, notified, "baz")
+}
+
+func TestStoreStopsNotifying(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.json")
+	writeFile(t, path, credentialsJSON(password))
+
+	s, err := NewStore(&Config{Path: path, Debounce: testDebounce})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notified := make(chan driver.Credentials, 10)
+	stop := s.Notify(func(creds driver.Credentials) {
+		notified <- creds
+	})
+	stop()
+
+	writeFile(t, path, credentialsJSON("baz"))
+	expectNoNotification(t, notified)
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,210 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+)
+
+// DefaultMaxStaleness is the default value of LastKnownGoodConfig.MaxStaleness.
+const DefaultMaxStaleness = 5 * time.Minute
+
+// LastKnownGoodConfig contains configuration information for a LastKnownGood store.
+type LastKnownGoodConfig struct {
+	// MaxStaleness is how long after they were last served by the store the cached credentials can
+	// be served in its place. Credentials that expire are never served past their expiry. It
+	// defaults to DefaultMaxStaleness.
+	MaxStaleness time.Duration
+	// IsTransient decides whether an error means the store is unavailable rather than that it
+	// refused to issue credentials. It defaults to IsTransportError.
+	IsTransient func(error) bool
+	// Logger logs when the store becomes degraded and when it recovers. It is optional.
+	Logger *slog.Logger
+}
+
+// LastKnownGoodStatus reports whether a LastKnownGood store is serving cached credentials.
+type LastKnownGoodStatus struct {
+	// Degraded is true while the store is unavailable and cached credentials are being served.
+	Degraded bool
+	// Since is when the store became unavailable.
+	Since time.Time
+	// Err is the last error returned by the store.
+	Err error
+}
+
+// LastKnownGood is a Store that serves the credentials it last got from the store it wraps when the
+// store is briefly unavailable, like when Vault can't be reached, so Connect doesn't fail while the
+// credentials are still valid.
+type LastKnownGood struct {
+	store        driver.Store
+	maxStaleness time.Duration
+	isTransient  func(error) bool
+	logger       *slog.Logger
+
+	mu     sync.Mutex
+	creds  driver.Credentials
+	served time.Time
+	status LastKnownGoodStatus
+}
+
+var (
+	_ driver.Store    = (*LastKnownGood)(nil)
+	_ driver.Revoker  = (*LastKnownGood)(nil)
+	_ driver.Notifier = (*LastKnownGood)(nil)
+	_ io.Closer       = (*LastKnownGood)(nil)
+)
+
+// NewLastKnownGood wraps s in a LastKnownGood store. cfg is optional.
+func NewLastKnownGood(s driver.Store, cfg *LastKnownGoodConfig) *LastKnownGood {
+	l := &LastKnownGood{
+		store:        s,
+		maxStaleness: DefaultMaxStaleness,
+		isTransient:  IsTransportError,
+	}
+
+	if cfg != nil {
+		if cfg.MaxStaleness > 0 {
+			l.maxStaleness = cfg.MaxStaleness
+		}
+
+		if cfg.IsTransient != nil {
+			l.isTransient = cfg.IsTransient
+		}
+
+		l.logger = cfg.Logger
+	}
+
+	return l
+}
+
+// Get implements the Store interface.
+func (l *LastKnownGood) Get(ctx context.Context) (driver.Credentials, error) {
+	creds, err := l.store.Get(ctx)
+
+	return l.result(ctx, creds, err)
+}
+
+// Refresh implements the Store interface.
+func (l *LastKnownGood) Refresh(ctx context.Context) (driver.Credentials, error) {
+	creds, err := l.store.Refresh(ctx)
+
+	return l.result(ctx, creds, err)
+}
+
+// Status reports whether cached credentials are being served.
+func (l *LastKnownGood) Status() LastKnownGoodStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.status
+}
+
+// Unwrap returns the wrapped store.
+func (l *LastKnownGood) Unwrap() driver.Store {
+	return l.store
+}
+
+// Revoke implements the driver.Revoker interface if the wrapped store does.
+func (l *LastKnownGood) Revoke(ctx context.Context) error {
+	if r, ok := l.store.(driver.Revoker); ok {
+		return r.Revoke(ctx)
+	}
+
+	return nil
+}
+
+// Close implements io.Closer if the wrapped store does.
+func (l *LastKnownGood) Close() error {
+	if closer, ok := l.store.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// Notify implements the driver.Notifier interface if the wrapped store does. Credentials it sends
+// become the last known good ones.
+func (l *LastKnownGood) Notify(fn func(driver.Credentials)) func() {
+	n, ok := l.store.(driver.Notifier)
+	if !ok {
+		return func() {}
+	}
+
+	return n.Notify(func(creds driver.Credentials) {
+		l.succeeded(context.Background(), creds)
+		fn(creds)
+	})
+}
+
+// result caches credentials the store served and serves the cached ones in place of a transient
+// error while they are fresh enough.
+func (l *LastKnownGood) result(ctx context.Context, creds driver.Credentials, err error) (driver.Credentials, error) {
+	if err == nil {
+		l.succeeded(ctx, creds)
+
+		return creds, nil
+	}
+
+	if !l.isTransient(err) {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.creds == nil || now.Sub(l.served) > l.maxStaleness {
+		return nil, err
+	}
+
+	if exp := driver.ExpiresAt(l.creds); !exp.IsZero() && !now.Before(exp) {
+		return nil, err
+	}
+
+	if !l.status.Degraded {
+		l.status.Since = now
+
+		if l.logger != nil {
+			l.logger.WarnContext(ctx, "store is unavailable, serving last known good credentials",
+				"age", now.Sub(l.served), "error", err)
+		}
+	}
+
+	l.status.Degraded = true
+	l.status.Err = err
+
+	return l.creds, nil
+}
+
+func (l *LastKnownGood) succeeded(ctx context.Context, creds driver.Credentials) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.status.Degraded && l.logger != nil {
+		l.logger.InfoContext(ctx, "store recovered", "degraded_for", time.Since(l.status.Since))
+	}
+
+	l.creds = creds
+	l.served = time.Now()
+	l.status = LastKnownGoodStatus{}
+}
+
+// IsTransportError reports whether err is a network error, like a connection being refused or timing
+// out, rather than the store refusing to issue credentials.
+func IsTransportError(err error) bool {
+	var netErr net.Error
+
+	return errors.As(err, &netErr) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,166 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+)
+
+var errTransport = &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+// flakyStore returns creds or err.
+type flakyStore struct {
+	creds driver.Credentials
+	err   error
+}
+
+func (s *flakyStore) Get(_ context.Context) (driver.Credentials, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	return s.creds, nil
+}
+
+func (s *flakyStore) Refresh(ctx context.Context) (driver.Credentials, error) {
+	return s.Get(ctx)
+}
+
+func TestLastKnownGoodServesCachedCredentials(t *testing.T) {
+	s := &flakyStore{creds: &Credential{Username: "foo", Password: "bar"}}
+	l := NewLastKnownGood(s, nil)
+
+	if _, err := l.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	s.err = fmt.Errorf("refreshing: %w", errTransport)
+
+	creds, err := l.Refresh(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if creds.GetPassword() != "bar" {
+		t.Fatalf("expected '%s' but got '%s' instead", "bar", creds.GetPassword())
+	}
+
+	status := l.Status()
+	if !status.Degraded || status.Since.IsZero() || !errors.Is(status.Err, errTransport) {
+		t.Fatalf("expected a degraded status but got '%+v' instead", status)
+	}
+
+	s.err = nil
+	s.creds = &Credential{Username: "foo", Password: "baz"}
+
+	if creds, err = l.Refresh(context.Background()); err != nil || creds.GetPassword() != "baz" {
+		t.Fatalf("expected '%s' but got '%v' instead", "baz", creds)
+	}
+
+	if status := l.Status(); status.Degraded {
+		t.Fatalf("expected the store to have recovered but got '%+v' instead", status)
+	}
+}
+
+func TestLastKnownGoodReturnsErrors(t *testing.T) {
+	errRejected := errors.New("permission denied")
+
+	testCases := []struct {
+		description string
+		creds       driver.Credentials
+		cfg         *LastKnownGoodConfig
+		served      time.Duration
+		err         error
+	}{
+		{
+			description: "non-transient error",
+			creds:       &Credential{Username: "foo", Password: "bar"},
+			err:         errRejected,
+		},
+		{
+			description: "too stale",
+			creds:       &Credential{Username: "foo", Password: "bar"},
+			cfg:         &LastKnownGoodConfig{MaxStaleness: time.Minute},
+			served:      2 * time.Minute,
+			err:         errTransport,
+		},
+		{
+			description: "expired",
+			creds:       &Credential{Username: "foo", Password: "bar", Expiration: time.Now().Add(-time.Second)},
+			err:         errTransport,
+		},
+		{
+			description: "custom classifier",
+			creds:       &Credential{Username: "foo", Password: "bar"},
+			cfg:         &LastKnownGoodConfig{IsTransient: func(error) bool { return false }},
+			err:         errTransport,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			s := &flakyStore{creds: tc.creds}
+			l := NewLastKnownGood(s, tc.cfg)
+
+			if _, err := l.Get(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			l.served = l.served.Add(-tc.served)
+			s.err = tc.err
+
+			if _, err := l.Refresh(context.Background()); !errors.Is(err, tc.err) {
+				t.Fatalf("expected '%v' but got '%v' instead", tc.err, err)
+			}
+
+			if status := l.Status(); status.Degraded {
+				t.Fatalf("expected the store not to be degraded but got '%+v' instead", status)
+			}
+		})
+	}
+
+	// Nothing is cached before the store has served credentials.
+	l := NewLastKnownGood(&flakyStore{err: errTransport}, nil)
+	if _, err := l.Get(context.Background()); !errors.Is(err, errTransport) {
+		t.Fatalf("expected '%v' but got '%v' instead", errTransport, err)
+	}
+}
+
+func TestIsTransportError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := ln.Addr().String()
+	if err := ln.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	//nolint:noctx
+	_, httpErr := http.Get("http://" + addr)
+
+	testCases := []struct {
+		description string
+		err         error
+		expected    bool
+	}{
+		{description: "refused connection", err: httpErr, expected: true},
+		{description: "deadline", err: context.DeadlineExceeded, expected: true},
+		{description: "other error", err: errors.New("permission denied"), expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			if IsTransportError(tc.err) != tc.expected {
+				t.Fatalf("expected '%v' but got '%v' instead for '%v'", tc.expected, !tc.expected, tc.err)
+			}
+		})
+	}
+}
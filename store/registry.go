@@ -0,0 +1,164 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+)
+
+// Factory creates a Store from a URL. The scheme of the URL is the name the Factory was registered
+// with and its query holds the parameters of the store.
+type Factory func(ctx context.Context, u *url.URL) (driver.Store, error)
+
+var (
+	// ErrMissingParam is returned in a ParamError when a required parameter isn't set.
+	ErrMissingParam = errors.New("parameter is required")
+	// ErrUnknownParam is returned in a ParamError when a parameter isn't one the store accepts.
+	ErrUnknownParam = errors.New("unknown parameter")
+)
+
+// ParamError reports a missing or invalid store parameter.
+type ParamError struct {
+	Store string
+	Param string
+	Err   error
+}
+
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("%s store: %s: %v", e.Store, e.Param, e.Err)
+}
+
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+// CheckParams returns a ParamError wrapping ErrUnknownParam for the first parameter of q, in sorted
+// order, that isn't one of known. Factories call it so a misspelled parameter is reported instead of
+// being silently ignored.
+func CheckParams(name string, q url.Values, known ...string) error {
+	params := make([]string, 0, len(q))
+	for k := range q {
+		params = append(params, k)
+	}
+
+	sort.Strings(params)
+
+	for _, param := range params {
+		if !slices.Contains(known, param) {
+			return &ParamError{Store: name, Param: param, Err: ErrUnknownParam}
+		}
+	}
+
+	return nil
+}
+
+type errFactoryAlreadyRegistered struct {
+	name string
+}
+
+func (e errFactoryAlreadyRegistered) Error() string {
+	return fmt.Sprintf("store factory %s already registered, ignoring", e.name)
+}
+
+type errUnknownStore struct {
+	name string
+}
+
+func (e errUnknownStore) Error() string {
+	return fmt.Sprintf("unknown store %q. Must be one of: %s", e.name, strings.Join(Stores(), ", "))
+}
+
+//nolint:gochecknoglobals
+var (
+	storeMu        sync.RWMutex
+	storeFactories = make(map[string]Factory)
+)
+
+func init() { //nolint:gochecknoinits
+	for name, f := range map[string]Factory{
+		StaticStoreName: NewStaticFromURL,
+		EnvStoreName:    NewEnvFromURL,
+		FileStoreName:   NewFileFromURL,
+	} {
+		if err := Register(name, f); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Register registers a store Factory under name so stores can be created by name from
+// configuration. Like driver.Register it doesn't panic on duplicate registrations, it returns an
+// error and keeps the original Factory.
+func Register(name string, f Factory) error {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	if f == nil {
+		panic(fmt.Sprintf("attempted to register store %s with a nil factory", name))
+	}
+
+	if _, registered := storeFactories[name]; registered {
+		return errFactoryAlreadyRegistered{name}
+	}
+
+	storeFactories[name] = f
+
+	return nil
+}
+
+// Stores returns the sorted names of the registered stores.
+func Stores() []string {
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	stores := make([]string, 0, len(storeFactories))
+	for k := range storeFactories {
+		stores = append(stores, k)
+	}
+
+	sort.Strings(stores)
+
+	return stores
+}
+
+// OpenURL creates a Store with the Factory registered under the scheme of u.
+func OpenURL(ctx context.Context, u *url.URL) (driver.Store, error) {
+	storeMu.RLock()
+	f, ok := storeFactories[u.Scheme]
+	storeMu.RUnlock()
+
+	if !ok {
+		return nil, errUnknownStore{u.Scheme}
+	}
+
+	return f(ctx, u)
+}
+
+// Open creates a Store from a URI such as vault://vault.example.com:8200?role=app with the Factory
+// registered under its scheme.
+func Open(ctx context.Context, uri string) (driver.Store, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	return OpenURL(ctx, u)
+}
+
+// OpenMap creates a Store with the Factory registered under name from a map of parameters, which
+// makes it possible to configure stores from configuration files as well as URIs.
+func OpenMap(ctx context.Context, name string, params map[string]string) (driver.Store, error) {
+	q := url.Values{}
+	for k, v := range params {
+		q.Set(k, v)
+	}
+
+	return OpenURL(ctx, &url.URL{Scheme: name, RawQuery: q.Encode()})
+}
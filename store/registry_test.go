@@ -0,0 +1,136 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+)
+
+type testStore struct {
+	params url.Values
+}
+
+func (s *testStore) Get(_ context.Context) (driver.Credentials, error) {
+	return &Credential{Username: s.params.Get("username"), Password: s.params.Get("password")}, nil
+}
+
+func (s *testStore) Refresh(ctx context.Context) (driver.Credentials, error) {
+	return s.Get(ctx)
+}
+
+func unregisterAllStores() {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	// For tests.
+	storeFactories = make(map[string]Factory)
+}
+
+func testFactory(_ context.Context, u *url.URL) (driver.Store, error) {
+	return &testStore{params: u.Query()}, nil
+}
+
+func TestRegisterStore(t *testing.T) {
+	unregisterAllStores()
+
+	if err := Register("test", testFactory); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Register("test", testFactory); err == nil {
+		t.Fatal("expected an error registering a store twice but got nil")
+	}
+
+	if stores := Stores(); !reflect.DeepEqual(stores, []string{"test"}) {
+		t.Fatalf("expected '%v' but got '%v' instead", []string{"test"}, stores)
+	}
+}
+
+func TestCantRegisterAStoreWithNilFactory(t *testing.T) {
+	unregisterAllStores()
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected a panic when attempting to register a nil factory")
+		}
+	}()
+
+	Register("test", nil) //nolint:errcheck
+}
+
+func TestOpenURL(t *testing.T) {
+	unregisterAllStores()
+
+	if err := Register("test", testFactory); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := OpenURL(context.Background(), &url.URL{Scheme: "test", RawQuery: "username=foo&password=bar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	creds, err := s.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if creds.GetUsername() != "foo" || creds.GetPassword() != "bar" {
+		t.Fatalf("expected '%s' but got '%v' instead", "foo", creds)
+	}
+
+	var unknown errUnknownStore
+	if _, err := OpenURL(context.Background(), &url.URL{Scheme: "missing"}); !errors.As(err, &unknown) {
+		t.Fatalf("expected '%T' but got '%v' instead", unknown, err)
+	}
+}
+
+func TestOpenAndOpenMap(t *testing.T) {
+	unregisterAllStores()
+
+	if err := Register("test", testFactory); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	fromURI, err := Open(ctx, "test://?username=foo&password=bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromMap, err := OpenMap(ctx, "test", map[string]string{"username": "foo", "password": "bar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []driver.Store{fromURI, fromMap} {
+		creds, err := s.Get(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if creds.GetUsername() != "foo" || creds.GetPassword() != "bar" {
+			t.Fatalf("expected '%s' but got '%v' instead", "foo", creds)
+		}
+	}
+
+	if _, err := Open(ctx, "://"); err == nil {
+		t.Fatal("expected an error but got nil")
+	}
+}
+
+func TestParamError(t *testing.T) {
+	err := error(&ParamError{Store: "vault", Param: "role", Err: ErrMissingParam})
+
+	if !errors.Is(err, ErrMissingParam) {
+		t.Fatalf("expected '%v' but got '%v' instead", ErrMissingParam, err)
+	}
+
+	if expected := "vault store: role: parameter is required"; err.Error() != expected {
+		t.Fatalf("expected '%s' but got '%s' instead", expected, err.Error())
+	}
+}
@@ -0,0 +1,56 @@
+package store
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+)
+
+// StaticStoreName is the name the Static store is registered with.
+const StaticStoreName = "static"
+
+// Static is a Store that always returns the same credentials. It is meant for local development
+// and tests, where the database password doesn't change.
+type Static struct {
+	creds *Credential
+}
+
+// NewStatic creates a Static store returning username and password.
+func NewStatic(username, password string) *Static {
+	return &Static{
+		creds: &Credential{
+			Username: username,
+			Password: password,
+		},
+	}
+}
+
+// Get implements the Store interface.
+func (s *Static) Get(_ context.Context) (driver.Credentials, error) {
+	return s.creds, nil
+}
+
+// Refresh implements the Store interface. The credentials can't change so it returns the same ones
+// as Get.
+func (s *Static) Refresh(ctx context.Context) (driver.Credentials, error) {
+	return s.Get(ctx)
+}
+
+// NewStaticFromURL creates a Static store from a URL like static://?username=app&password=secret.
+// Both parameters are required.
+func NewStaticFromURL(_ context.Context, u *url.URL) (driver.Store, error) {
+	q := u.Query()
+
+	if err := CheckParams(StaticStoreName, q, "username", "password"); err != nil {
+		return nil, err
+	}
+
+	for _, param := range []string{"username", "password"} {
+		if q.Get(param) == "" {
+			return nil, &ParamError{Store: StaticStoreName, Param: param, Err: ErrMissingParam}
+		}
+	}
+
+	return NewStatic(q.Get("username"), q.Get("password")), nil
+}
@@ -0,0 +1,71 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+)
+
+func TestStatic(t *testing.T) {
+	s := NewStatic("foo", "bar")
+
+	for _, get := range []func(context.Context) (driver.Credentials, error){s.Get, s.Refresh} {
+		creds, err := get(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if creds.GetUsername() != "foo" || creds.GetPassword() != "bar" {
+			t.Fatalf("expected '%s' but got '%v' instead", "foo", creds)
+		}
+	}
+}
+
+func TestNewStaticFromURL(t *testing.T) {
+	testCases := []struct {
+		description string
+		query       string
+		param       string
+	}{
+		{
+			description: "valid",
+			query:       "username=foo&password=bar",
+		},
+		{
+			description: "missing username",
+			query:       "password=bar",
+			param:       "username",
+		},
+		{
+			description: "missing password",
+			query:       "username=foo",
+			param:       "password",
+		},
+		{
+			description: "unknown parameter",
+			query:       "username=foo&password=bar&pasword=bar",
+			param:       "pasword",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			_, err := NewStaticFromURL(context.Background(), &url.URL{Scheme: StaticStoreName, RawQuery: tc.query})
+			if tc.param == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				return
+			}
+
+			var paramErr *ParamError
+			if !errors.As(err, &paramErr) || paramErr.Param != tc.param {
+				t.Fatalf("expected a missing '%s' but got '%v' instead", tc.param, err)
+			}
+		})
+	}
+}
@@ -1,9 +1,24 @@
 package store
 
-// Credential implements the Credentials interface.
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+)
+
+// Credential implements the Credentials interface. Its password is masked when it is printed with
+// the fmt package or logged with log/slog.
 type Credential struct {
 	Username string
 	Password string
+	// Expiration is the time the credential stops being valid. It is optional and a zero value
+	// means the credential doesn't expire on a known schedule.
+	Expiration time.Time
+	// Token marks the password as a login token, like an RDS IAM authentication token, that is only
+	// checked when a connection is opened. See driver.TokenCredentials.
+	Token bool
 }
 
 // GetUsername implements the Credentials interface.
@@ -15,3 +30,48 @@ func (c *Credential) GetUsername() string {
 func (c *Credential) GetPassword() string {
 	return c.Password
 }
+
+// ExpiresAt implements the ExpiringCredentials interface.
+func (c *Credential) ExpiresAt() time.Time {
+	return c.Expiration
+}
+
+// IsToken implements the TokenCredentials interface.
+func (c *Credential) IsToken() bool {
+	return c.Token
+}
+
+// LogValue implements slog.LogValuer so the password isn't logged.
+func (c Credential) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("username", c.Username),
+		slog.String("password", c.redactedPassword()),
+	}
+
+	if !c.Expiration.IsZero() {
+		attrs = append(attrs, slog.Time("expiration", c.Expiration))
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// Format implements fmt.Formatter so the password isn't printed by any verb.
+func (c Credential) Format(f fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && f.Flag('#'):
+		fmt.Fprintf(f, "store.Credential{Username:%q, Password:%q, Expiration:%#v}",
+			c.Username, c.redactedPassword(), c.Expiration)
+	case verb == 'v' && f.Flag('+'):
+		fmt.Fprintf(f, "{Username:%s Password:%s Expiration:%v}", c.Username, c.redactedPassword(), c.Expiration)
+	default:
+		fmt.Fprintf(f, "{%s %s %v}", c.Username, c.redactedPassword(), c.Expiration)
+	}
+}
+
+func (c Credential) redactedPassword() string {
+	if c.Password == "" {
+		return ""
+	}
+
+	return driver.Redacted
+}
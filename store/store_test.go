@@ -0,0 +1,82 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCredentialMasksPassword(t *testing.T) {
+	cred := &Credential{
+		Username:   "username",
+		Password:   "password",
+		Expiration: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	testCases := []struct {
+		description string
+		format      string
+		arg         any
+		expected    string
+	}{
+		{
+			description: "pointer %v",
+			format:      "%v",
+			arg:         cred,
+			expected:    "{username *** 2024-01-01 00:00:00 +0000 UTC}",
+		},
+		{
+			description: "value %v",
+			format:      "%v",
+			arg:         *cred,
+			expected:    "{username *** 2024-01-01 00:00:00 +0000 UTC}",
+		},
+		{
+			description: "%+v",
+			format:      "%+v",
+			arg:         cred,
+			expected:    "{Username:username Password:*** Expiration:2024-01-01 00:00:00 +0000 UTC}",
+		},
+		{
+			description: "%#v",
+			format:      "%#v",
+			arg:         cred,
+			expected: `store.Credential{Username:"username", Password:"***", ` +
+				`Expiration:time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)}`,
+		},
+		{
+			description: "%s",
+			format:      "%s",
+			arg:         cred,
+			expected:    "{username *** 2024-01-01 00:00:00 +0000 UTC}",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			if s := fmt.Sprintf(tc.format, tc.arg); s != tc.expected {
+				t.Fatalf("expected '%s' but got '%s' instead", tc.expected, s)
+			}
+		})
+	}
+}
+
+func TestCredentialLogValueMasksPassword(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	logger.Info("credentials", "creds", &Credential{Username: "username", Password: "password"})
+
+	logs := buf.String()
+
+	if strings.Contains(logs, "password=password") {
+		t.Fatalf("expected logs not to contain the password but got '%s'", logs)
+	}
+
+	if !strings.Contains(logs, "creds.username=username creds.password=***") {
+		t.Fatalf("expected logs to contain the masked credential but got '%s'", logs)
+	}
+}
@@ -8,15 +8,18 @@ import (
 	"github.com/hashicorp/vault-client-go"
 )
 
-// Map handles mapping data from a file on disk to a Credentials object. This
-// allows consumers to define how their credential data is structured.
-type Mapper func(s string) (*store.Credential, error)
+// Mapper handles mapping data from a file on disk to a Credentials object. This
+// allows consumers to define how their credential data is structured. It is the
+// same type as store.Mapper so mappers can be shared with store.File.
+type Mapper = store.Mapper
 
 // AgentDatabaseCredentials gets DB credentials the Vault Agent creates on disk
 // See: https://www.vaultproject.io/docs/agent/index.html
 // One of the key features of the Vault agent is that it can spit out credentials
 // using Consul template markup. See https://www.vaultproject.io/docs/agent/template
 // for details.
+// The file is only read again when the Connector refreshes its credentials. The
+// filewatch store reads it as soon as the agent rewrites it.
 type AgentDatabaseCredentials struct {
 	mapper Mapper
 	path   string
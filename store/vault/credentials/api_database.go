@@ -3,6 +3,7 @@ package vaultcredentials
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/davepgreene/go-db-credential-refresh/store"
 	"github.com/hashicorp/vault-client-go"
@@ -11,8 +12,10 @@ import (
 // APIDatabaseCredentials gets DB credentials from the Vault Database Secrets engine
 // See: https://www.vaultproject.io/docs/secrets/databases/
 type APIDatabaseCredentials struct {
-	path string
-	role string
+	path  string
+	role  string
+	mu    sync.Mutex
+	lease Lease
 }
 
 // NewAPIDatabaseCredentials creates a new credential location backed by Vault's DB Secrets engine.
@@ -32,7 +35,24 @@ func NewAPIDatabaseCredentials(role, path string) CredentialLocation {
 
 // GetCredentials implements the CredentialLocation interface.
 func (db *APIDatabaseCredentials) GetCredentials(ctx context.Context, client *vault.Client) (string, error) {
-	return GetFromVaultSecretsAPI(ctx, client, "", fmt.Sprintf("%s/creds/%s", db.path, db.role))
+	s, lease, err := readSecret(ctx, client, "", fmt.Sprintf("%s/creds/%s", db.path, db.role))
+	if err != nil {
+		return "", err
+	}
+
+	db.mu.Lock()
+	db.lease = lease
+	db.mu.Unlock()
+
+	return s, nil
+}
+
+// Lease implements the LeasedCredentialLocation interface.
+func (db *APIDatabaseCredentials) Lease() Lease {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return db.lease
 }
 
 // Map implements the CredentialLocation interface.
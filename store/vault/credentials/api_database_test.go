@@ -3,8 +3,12 @@ package vaultcredentials
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/vault-client-go"
 	"github.com/hashicorp/vault-client-go/schema"
 
 	"github.com/davepgreene/go-db-credential-refresh/store/vault/vaulttest"
@@ -68,3 +72,48 @@ func TestNewAPIDatabaseCredentials(t *testing.T) {
 		t.Fatalf("expected password to be %s but got %s instead", password, creds.GetPassword())
 	}
 }
+
+func TestAPIDatabaseCredentialsTracksLease(t *testing.T) {
+	leaseID := "database/creds/postgres/abc123"
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/database/creds/postgres" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"lease_id": "%s", "lease_duration": 3600, "renewable": true, "data": {"username": "%s", "password": "%s"}}`,
+			leaseID, username, password)
+	}))
+	defer ts.Close()
+
+	client, err := vault.New(vault.WithAddress(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	adc := NewAPIDatabaseCredentials("postgres", "")
+	if _, err := adc.GetCredentials(context.Background(), client); err != nil {
+		t.Fatal(err)
+	}
+
+	lcl, ok := adc.(LeasedCredentialLocation)
+	if !ok {
+		t.Fatalf("expected a LeasedCredentialLocation but got a %T instead", adc)
+	}
+
+	lease := lcl.Lease()
+	if lease.ID != leaseID {
+		t.Fatalf("expected lease ID to be '%s' but got '%s' instead", leaseID, lease.ID)
+	}
+
+	if lease.Duration != time.Hour {
+		t.Fatalf("expected lease duration to be %s but got %s instead", time.Hour, lease.Duration)
+	}
+
+	if !lease.Renewable {
+		t.Fatal("expected lease to be renewable")
+	}
+}
@@ -2,6 +2,7 @@ package vaultcredentials
 
 import (
 	"context"
+	"time"
 
 	"github.com/davepgreene/go-db-credential-refresh/store"
 	"github.com/hashicorp/vault-client-go"
@@ -13,6 +14,21 @@ type CredentialLocation interface {
 	Map(s string) (*store.Credential, error)
 }
 
+// Lease describes the Vault lease attached to a set of dynamic credentials.
+type Lease struct {
+	ID        string
+	Duration  time.Duration
+	Renewable bool
+}
+
+// LeasedCredentialLocation is an optional interface for CredentialLocations whose credentials are
+// backed by a Vault lease. The Vault store uses the lease to work out when credentials expire.
+type LeasedCredentialLocation interface {
+	CredentialLocation
+	// Lease returns the lease of the credentials most recently returned by GetCredentials.
+	Lease() Lease
+}
+
 // Credentials represents an abstraction over a username and password.
 type Credentials interface {
 	GetUsername() string
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"time"
 
 	"github.com/hashicorp/vault-client-go"
 )
@@ -14,6 +15,14 @@ var (
 
 // GetFromVaultSecretsAPI is a wrapper over logical reads from a Vault path with marshalling and error handling.
 func GetFromVaultSecretsAPI(ctx context.Context, client *vault.Client, mountPath string, path string) (string, error) {
+	s, _, err := readSecret(ctx, client, mountPath, path)
+
+	return s, err
+}
+
+// readSecret reads a Vault path and returns the marshalled secret data along with the lease
+// attached to it.
+func readSecret(ctx context.Context, client *vault.Client, mountPath string, path string) (string, Lease, error) {
 	opts := make([]vault.RequestOption, 0)
 	if mountPath != "" {
 		opts = append(opts, vault.WithMountPath(mountPath))
@@ -21,21 +30,25 @@ func GetFromVaultSecretsAPI(ctx context.Context, client *vault.Client, mountPath
 
 	resp, err := client.Read(ctx, path, opts...)
 	if err != nil {
-		return "", err
+		return "", Lease{}, err
 	}
 
 	// If Vault can't handle the path it will return a nil response with no error
 	// so it's important to nil check it so we don't accidentally try to marshal it.
 	if resp == nil {
-		return "", errInvalidPath
+		return "", Lease{}, errInvalidPath
 	}
 
 	// Something in Vault's API would have to be horribly broken for the response
 	// not to be marshalable but it's worth error checking it as a matter of habit.
 	b, err := json.Marshal(resp.Data)
 	if err != nil {
-		return "", err
+		return "", Lease{}, err
 	}
 
-	return string(b), nil
+	return string(b), Lease{
+		ID:        resp.LeaseID,
+		Duration:  time.Duration(resp.LeaseDuration) * time.Second,
+		Renewable: resp.Renewable,
+	}, nil
 }
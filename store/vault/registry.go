@@ -0,0 +1,184 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+	"github.com/davepgreene/go-db-credential-refresh/store"
+	"github.com/hashicorp/vault-client-go"
+
+	vaultauth "github.com/davepgreene/go-db-credential-refresh/store/vault/auth"
+	vaultcredentials "github.com/davepgreene/go-db-credential-refresh/store/vault/credentials"
+)
+
+// StoreName is the name the Vault store is registered with in the store package.
+const StoreName = "vault"
+
+const credentialsPrefix = "credentials_"
+
+var errUnknownValue = errors.New("unknown value")
+
+func init() { //nolint:gochecknoinits
+	if err := store.Register(StoreName, NewStoreFromURL); err != nil {
+		panic(err)
+	}
+}
+
+// NewStoreFromURL creates a Vault store from a URL like
+//
+//	vault://vault.example.com:8200?auth=kubernetes&auth_role=app&credentials=database&role=app
+//
+// It is registered with the store package so importing this package makes vault:// URIs available
+// to store.Open. The host of the URL is the Vault address, which is reached over HTTPS unless tls is
+// false. The address can also be given in full as the address parameter. Without either it is read
+// from the environment like the Vault CLI does. The query accepts:
+//
+//   - auth: token or kubernetes. Without it the token is read from auth_token or VAULT_TOKEN.
+//   - auth_token: the Vault token for token auth.
+//   - auth_role and auth_path: the role and service account token path for kubernetes auth.
+//   - credentials: database, kv or agent. It defaults to database.
+//   - credentials_role and credentials_mount: the role and mount path for database credentials.
+//   - credentials_mount and credentials_path: the mount path and secret path for kv credentials.
+//   - credentials_path: the file Vault Agent renders for agent credentials.
+//   - revoke_token: whether to revoke the Vault token when the store is revoked.
+//
+// The credentials_ prefix can be left off, e.g. vault://vault.example.com:8200?role=app.
+func NewStoreFromURL(_ context.Context, u *url.URL) (driver.Store, error) {
+	q := u.Query()
+
+	if err := store.CheckParams(StoreName, q, "address", "tls", "auth", "auth_token", "auth_role", "auth_path",
+		"credentials", "credentials_role", "credentials_mount", "credentials_path", "role", "mount", "path",
+		"revoke_token"); err != nil {
+		return nil, err
+	}
+
+	client, err := newClientFromURL(u)
+	if err != nil {
+		return nil, err
+	}
+
+	tl, err := tokenLocationFromQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	cl, err := credentialLocationFromQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	var revokeToken bool
+	if v := q.Get("revoke_token"); v != "" {
+		if revokeToken, err = strconv.ParseBool(v); err != nil {
+			return nil, paramError("revoke_token", err)
+		}
+	}
+
+	return NewStore(&Config{
+		Client:             client,
+		TokenLocation:      tl,
+		CredentialLocation: cl,
+		RevokeToken:        revokeToken,
+	})
+}
+
+func newClientFromURL(u *url.URL) (*vault.Client, error) {
+	opts := []vault.ClientOption{vault.WithEnvironment()}
+
+	if address := u.Query().Get("address"); address != "" {
+		opts = append(opts, vault.WithAddress(address))
+	} else if u.Host != "" {
+		scheme := "https"
+		if v := u.Query().Get("tls"); v != "" {
+			useTLS, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, paramError("tls", err)
+			}
+
+			if !useTLS {
+				scheme = "http"
+			}
+		}
+
+		opts = append(opts, vault.WithAddress((&url.URL{Scheme: scheme, Host: u.Host}).String()))
+	}
+
+	return vault.New(opts...)
+}
+
+func tokenLocationFromQuery(q url.Values) (TokenLocation, error) {
+	switch q.Get("auth") {
+	case "":
+		if token := q.Get("auth_token"); token != "" {
+			return vaultauth.NewTokenAuth(token), nil
+		}
+
+		// NewStore looks up the token the client read from the environment.
+		return nil, nil
+	case "token":
+		token := q.Get("auth_token")
+		if token == "" {
+			return nil, paramError("auth_token", store.ErrMissingParam)
+		}
+
+		return vaultauth.NewTokenAuth(token), nil
+	case "kubernetes":
+		role := q.Get("auth_role")
+		if role == "" {
+			return nil, paramError("auth_role", store.ErrMissingParam)
+		}
+
+		return vaultauth.NewKubernetesAuth(role, q.Get("auth_path")), nil
+	default:
+		return nil, paramError("auth", errUnknownValue)
+	}
+}
+
+func credentialLocationFromQuery(q url.Values) (vaultcredentials.CredentialLocation, error) {
+	// credentialParam gets a credentials_ parameter, which can also be given without the prefix.
+	credentialParam := func(name string) string {
+		if v := q.Get(credentialsPrefix + name); v != "" {
+			return v
+		}
+
+		return q.Get(name)
+	}
+
+	switch q.Get("credentials") {
+	case "", "database":
+		role := credentialParam("role")
+		if role == "" {
+			return nil, paramError(credentialsPrefix+"role", store.ErrMissingParam)
+		}
+
+		return vaultcredentials.NewAPIDatabaseCredentials(role, credentialParam("mount")), nil
+	case "kv":
+		path := credentialParam("path")
+		if path == "" {
+			return nil, paramError(credentialsPrefix+"path", store.ErrMissingParam)
+		}
+
+		mount := credentialParam("mount")
+		if mount == "" {
+			mount = "secret"
+		}
+
+		return vaultcredentials.NewKvCredentials(mount, path), nil
+	case "agent":
+		path := credentialParam("path")
+		if path == "" {
+			return nil, paramError(credentialsPrefix+"path", store.ErrMissingParam)
+		}
+
+		return vaultcredentials.NewAgentDatabaseCredentials(vaultcredentials.DefaultMapper, path), nil
+	default:
+		return nil, paramError("credentials", errUnknownValue)
+	}
+}
+
+func paramError(param string, err error) error {
+	return &store.ParamError{Store: StoreName, Param: param, Err: err}
+}
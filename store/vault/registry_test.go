@@ -0,0 +1,155 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+	"github.com/davepgreene/go-db-credential-refresh/store"
+)
+
+func TestStoreIsRegistered(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Header.Get("X-Vault-Token") != token {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/auth/token/lookup-self":
+			fmt.Fprintf(w, `{"data": {"id": "%s"}}`, token)
+		case "/v1/db/creds/app":
+			fmt.Fprintf(w, `{"lease_id": "db/creds/app/1", "lease_duration": 60, "data": {"username": "%s", "password": "%s"}}`,
+				username, password)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	fromURI, err := store.Open(ctx, fmt.Sprintf("vault://%s?tls=false&auth=token&auth_token=%s&role=app&mount=db",
+		u.Host, token))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromMap, err := store.OpenMap(ctx, StoreName, map[string]string{
+		"address":           ts.URL,
+		"auth":              "token",
+		"auth_token":        token,
+		"credentials":       "database",
+		"credentials_role":  "app",
+		"credentials_mount": "db",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []driver.Store{fromURI, fromMap} {
+		creds, err := s.Get(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if creds.GetUsername() != username || creds.GetPassword() != password {
+			t.Fatalf("expected '%s' but got '%s' instead", username, creds.GetUsername())
+		}
+	}
+}
+
+func TestNewStoreFromURLReportsInvalidParams(t *testing.T) {
+	testCases := []struct {
+		description string
+		query       string
+		param       string
+		err         error
+	}{
+		{
+			description: "unknown auth",
+			query:       "auth=ldap&role=app",
+			param:       "auth",
+			err:         errUnknownValue,
+		},
+		{
+			description: "token auth without token",
+			query:       "auth=token&role=app",
+			param:       "auth_token",
+			err:         store.ErrMissingParam,
+		},
+		{
+			description: "kubernetes auth without role",
+			query:       "auth=kubernetes&role=app",
+			param:       "auth_role",
+			err:         store.ErrMissingParam,
+		},
+		{
+			description: "database credentials without role",
+			query:       "auth_token=token",
+			param:       "credentials_role",
+			err:         store.ErrMissingParam,
+		},
+		{
+			description: "kv credentials without path",
+			query:       "auth_token=token&credentials=kv",
+			param:       "credentials_path",
+			err:         store.ErrMissingParam,
+		},
+		{
+			description: "agent credentials without path",
+			query:       "auth_token=token&credentials=agent",
+			param:       "credentials_path",
+			err:         store.ErrMissingParam,
+		},
+		{
+			description: "unknown credentials",
+			query:       "auth_token=token&credentials=transit",
+			param:       "credentials",
+			err:         errUnknownValue,
+		},
+		{
+			description: "invalid tls",
+			query:       "tls=maybe",
+			param:       "tls",
+		},
+		{
+			description: "unknown parameter",
+			query:       "auth_token=token&role=app&revoke_tokn=true",
+			param:       "revoke_tokn",
+			err:         store.ErrUnknownParam,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			_, err := store.Open(context.Background(), "vault://vault.example.com:8200?"+tc.query)
+
+			var paramErr *store.ParamError
+			if !errors.As(err, &paramErr) {
+				t.Fatalf("expected '%T' but got '%v' instead", paramErr, err)
+			}
+
+			if paramErr.Param != tc.param {
+				t.Fatalf("expected '%s' but got '%s' instead", tc.param, paramErr.Param)
+			}
+
+			if tc.err != nil && !errors.Is(err, tc.err) {
+				t.Fatalf("expected '%v' but got '%v' instead", tc.err, err)
+			}
+		})
+	}
+}
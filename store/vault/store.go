@@ -3,10 +3,16 @@ package vault
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/davepgreene/go-db-credential-refresh/driver"
 	"github.com/hashicorp/vault-client-go"
+	"github.com/hashicorp/vault-client-go/schema"
 
 	vaultauth "github.com/davepgreene/go-db-credential-refresh/store/vault/auth"
 	vaultcredentials "github.com/davepgreene/go-db-credential-refresh/store/vault/credentials"
@@ -22,7 +28,33 @@ type Store struct {
 	client *vault.Client
 	cl     vaultcredentials.CredentialLocation
 	tl     TokenLocation
-	creds  driver.Credentials
+	// mu guards creds and leases, which are used by the Connector's background refresh, Notify and
+	// Close at the same time. It is never held while calling Vault.
+	mu    sync.Mutex
+	creds driver.Credentials
+	// leases are the unexpired leases of every set of credentials issued since the store was last
+	// revoked. Connections opened with earlier credentials may still be using them so they are all
+	// revoked.
+	leases      []lease
+	revokeToken bool
+	logger      *slog.Logger
+	now         func() time.Time
+}
+
+// lease is a credential lease and when it expires. Leases without a duration never expire.
+type lease struct {
+	id      string
+	expires time.Time
+}
+
+// sameID reports whether o is the same lease as l.
+func (l lease) sameID(o lease) bool {
+	return l.id == o.id
+}
+
+// expired reports whether Vault has already revoked the lease by now.
+func (l lease) expired(now time.Time) bool {
+	return !l.expires.IsZero() && !now.Before(l.expires)
 }
 
 // Config contains configuration information.
@@ -30,6 +62,13 @@ type Config struct {
 	Client             *vault.Client
 	TokenLocation      TokenLocation
 	CredentialLocation vaultcredentials.CredentialLocation
+	// RevokeToken revokes the Vault token along with the credential lease when the store is
+	// revoked. It should only be set when the token is owned by the store, e.g. when it was issued
+	// by Kubernetes auth for this process.
+	RevokeToken bool
+	// Logger logs fetching and revoking credentials. Credentials and tokens are never logged. It is
+	// optional.
+	Logger *slog.Logger
 }
 
 var (
@@ -88,17 +127,31 @@ func NewStore(c *Config) (*Store, error) {
 		return nil, err
 	}
 
+	logger := c.Logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+
+	logger.DebugContext(ctx, "authenticated to vault", "token_location", fmt.Sprintf("%T", c.TokenLocation))
+
 	return &Store{
-		client: client,
-		tl:     c.TokenLocation,
-		cl:     c.CredentialLocation,
+		client:      client,
+		tl:          c.TokenLocation,
+		cl:          c.CredentialLocation,
+		revokeToken: c.RevokeToken,
+		logger:      logger,
+		now:         time.Now,
 	}, nil
 }
 
 // Get implements the Store interface.
 func (v *Store) Get(ctx context.Context) (driver.Credentials, error) {
-	if v.creds != nil {
-		return v.creds, nil
+	v.mu.Lock()
+	creds := v.creds
+	v.mu.Unlock()
+
+	if creds != nil {
+		return creds, nil
 	}
 
 	return v.Refresh(ctx)
@@ -106,6 +159,8 @@ func (v *Store) Get(ctx context.Context) (driver.Credentials, error) {
 
 // Refresh implements the store interface.
 func (v *Store) Refresh(ctx context.Context) (driver.Credentials, error) {
+	issued := v.now()
+
 	credStr, err := v.cl.GetCredentials(ctx, v.client)
 	if err != nil {
 		return nil, err
@@ -116,8 +171,96 @@ func (v *Store) Refresh(ctx context.Context) (driver.Credentials, error) {
 		return nil, err
 	}
 
+	var issuedLease lease
+
+	// Dynamic credentials expire with their lease so we pass that along to the Connector, which
+	// can then refresh them before Vault revokes them.
+	if lcl, ok := v.cl.(vaultcredentials.LeasedCredentialLocation); ok && creds != nil {
+		l := lcl.Lease()
+		issuedLease.id = l.ID
+
+		if l.Duration > 0 {
+			creds.Expiration = issued.Add(l.Duration)
+			issuedLease.expires = creds.Expiration
+		}
+
+		v.logger.DebugContext(ctx, "fetched leased credentials from vault",
+			"lease_duration", l.Duration, "renewable", l.Renewable)
+	} else {
+		v.logger.DebugContext(ctx, "fetched credentials from vault")
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
 	// Cache the credentials
 	v.creds = creds
+	v.leases = v.unexpiredLeases()
+
+	if issuedLease.id != "" && !slices.ContainsFunc(v.leases, issuedLease.sameID) {
+		v.leases = append(v.leases, issuedLease)
+	}
 
 	return creds, nil
 }
+
+// unexpiredLeases returns the leases Vault hasn't revoked yet. It must be called with v.mu held.
+func (v *Store) unexpiredLeases() []lease {
+	now := v.now()
+
+	return slices.DeleteFunc(v.leases, func(l lease) bool { return l.expired(now) })
+}
+
+// Revoke implements the driver.Revoker interface. It revokes the lease of every set of credentials the
+// store has issued since it was last revoked, not just the current one, so Vault drops the database
+// users right away instead of when their leases expire. Leases that have already expired are skipped.
+// It revokes the Vault token too when Config.RevokeToken is set. Leases that fail to be revoked are
+// kept so a later call can retry them.
+func (v *Store) Revoke(ctx context.Context) error {
+	v.mu.Lock()
+	if len(v.leases) > 0 {
+		v.creds = nil
+	}
+
+	leases := v.unexpiredLeases()
+	v.leases = nil
+	v.mu.Unlock()
+
+	var (
+		errs   []error
+		failed []lease
+	)
+
+	for _, l := range leases {
+		if _, err := v.client.System.LeasesRevokeLease(ctx, schema.LeasesRevokeLeaseRequest{
+			LeaseId: l.id,
+		}); err != nil {
+			errs = append(errs, err)
+			failed = append(failed, l)
+
+			continue
+		}
+
+		v.logger.InfoContext(ctx, "revoked vault lease")
+	}
+
+	if len(failed) > 0 {
+		v.mu.Lock()
+		v.leases = append(failed, v.leases...)
+		v.mu.Unlock()
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	if v.revokeToken {
+		if _, err := v.client.Auth.TokenRevokeSelf(ctx); err != nil {
+			return err
+		}
+
+		v.logger.InfoContext(ctx, "revoked vault token")
+	}
+
+	return nil
+}
@@ -2,9 +2,15 @@ package vault
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/davepgreene/go-db-credential-refresh/store"
 	"github.com/hashicorp/vault-client-go"
@@ -40,6 +46,15 @@ func (tcl *testCredentialLocation) Map(s string) (*store.Credential, error) {
 	return tcl.Mapper(s)
 }
 
+type testLeasedCredentialLocation struct {
+	testCredentialLocation
+	lease vaultcredentials.Lease
+}
+
+func (tlcl *testLeasedCredentialLocation) Lease() vaultcredentials.Lease {
+	return tlcl.lease
+}
+
 func TestNewStoreCannotCreateWithoutValidConfig(t *testing.T) {
 	if _, err := NewStore(nil); err == nil {
 		t.Fatal("expected an error but didn't get one")
@@ -339,3 +354,189 @@ func TestStoreWithCachedCredentials(t *testing.T) {
 		t.Fatalf("expected the mapper function to only be called once but it was called %d times", mapCallCount)
 	}
 }
+
+func TestStoreSetsExpirationFromLease(t *testing.T) {
+	client, err := vault.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewStore(&Config{
+		Client: client,
+		TokenLocation: &testTokenLocation{
+			TokenGetter: func(_ context.Context, _ *vault.Client) (string, error) {
+				return token, nil
+			},
+		},
+		CredentialLocation: &testLeasedCredentialLocation{
+			testCredentialLocation: testCredentialLocation{
+				CredentialGetter: func(_ context.Context, _ *vault.Client) (string, error) {
+					return fmt.Sprintf(`{"username": "%s", "password": "%s"}`, username, password), nil
+				},
+				Mapper: vaultcredentials.DefaultMapper,
+			},
+			lease: vaultcredentials.Lease{
+				ID:       "database/creds/role/abc123",
+				Duration: time.Hour,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	creds, err := s.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ec, ok := creds.(*store.Credential)
+	if !ok {
+		t.Fatalf("expected a *store.Credential but got a %T instead", creds)
+	}
+
+	if remaining := time.Until(ec.ExpiresAt()); remaining <= 0 || remaining > time.Hour {
+		t.Fatalf("expected credentials to expire within %s but they expire in %s", time.Hour, remaining)
+	}
+}
+
+// testLeaseServer is a stand-in for Vault that issues database credentials with a new lease every
+// time and records the leases and tokens that are revoked.
+type testLeaseServer struct {
+	mu            sync.Mutex
+	issued        int
+	revokedLeases []string
+	tokenRevoked  bool
+}
+
+func (ls *testLeaseServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.URL.Path {
+	case "/v1/database/creds/app":
+		ls.issued++
+		fmt.Fprintf(w, `{"lease_id": "database/creds/app/%d", "lease_duration": 3600, `+
+			`"data": {"username": "%s%d", "password": "%s"}}`, ls.issued, username, ls.issued, password)
+	case "/v1/sys/leases/revoke":
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		lease, _ := body["lease_id"].(string)
+		ls.revokedLeases = append(ls.revokedLeases, lease)
+		w.WriteHeader(http.StatusNoContent)
+	case "/v1/auth/token/revoke-self":
+		ls.tokenRevoked = true
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestLeaseStore(t *testing.T, ls *testLeaseServer) *Store {
+	t.Helper()
+
+	ts := httptest.NewServer(ls)
+	t.Cleanup(ts.Close)
+
+	client, err := vault.New(vault.WithAddress(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewStore(&Config{
+		Client: client,
+		TokenLocation: &testTokenLocation{
+			TokenGetter: func(_ context.Context, _ *vault.Client) (string, error) {
+				return token, nil
+			},
+		},
+		CredentialLocation: vaultcredentials.NewAPIDatabaseCredentials("app", ""),
+		RevokeToken:        true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func TestStoreRevokesLeasesAndToken(t *testing.T) {
+	ls := &testLeaseServer{}
+	s := newTestLeaseStore(t, ls)
+	ctx := context.Background()
+
+	if _, err := s.Get(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Connections opened with the credentials of earlier leases can outlive a refresh so every lease
+	// is revoked, not just the latest one.
+	for range 2 {
+		if _, err := s.Refresh(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.Revoke(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	expected := []string{"database/creds/app/1", "database/creds/app/2", "database/creds/app/3"}
+	if !reflect.DeepEqual(ls.revokedLeases, expected) {
+		t.Fatalf("expected '%v' but got '%v' instead", expected, ls.revokedLeases)
+	}
+
+	if len(s.leases) != 0 {
+		t.Fatalf("expected no leases to be left but got '%v' instead", s.leases)
+	}
+
+	if !ls.tokenRevoked {
+		t.Fatal("expected token to be revoked")
+	}
+}
+
+func TestStoreDropsExpiredLeases(t *testing.T) {
+	ls := &testLeaseServer{}
+	s := newTestLeaseStore(t, ls)
+	ctx := context.Background()
+
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	if _, err := s.Get(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Vault has already revoked the first lease by the time the second one is issued.
+	now = now.Add(2 * time.Hour)
+
+	if _, err := s.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(s.leases) != 1 {
+		t.Fatalf("expected 1 lease to be left but got '%v' instead", s.leases)
+	}
+
+	if err := s.Revoke(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	expected := []string{"database/creds/app/2"}
+	if !reflect.DeepEqual(ls.revokedLeases, expected) {
+		t.Fatalf("expected '%v' but got '%v' instead", expected, ls.revokedLeases)
+	}
+}
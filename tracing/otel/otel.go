@@ -0,0 +1,204 @@
+// Package otel traces go-db-credential-refresh Connectors and Stores with OpenTelemetry.
+//
+// A Tracer set on driver.Config creates a span for every call to Connector.Connect with a child
+// span for each attempt to open a connection. Wrapping the Store with WrapStore adds child spans for
+// the calls to Store.Get and Store.Refresh, which separates time spent getting credentials from
+// Vault or signing RDS IAM tokens from time spent connecting to the database.
+package otel
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/davepgreene/go-db-credential-refresh/driver"
+)
+
+// ScopeName is the instrumentation scope of the tracer used to create spans.
+const ScopeName = "github.com/davepgreene/go-db-credential-refresh/tracing/otel"
+
+// Span names and attributes. Spans never include credentials.
+const (
+	SpanConnect = "db.credentials.connect"
+	SpanOpen    = "db.credentials.open"
+	SpanGet     = "db.credentials.store.get"
+	SpanRefresh = "db.credentials.store.refresh"
+
+	AttributeDriver      = attribute.Key("db.credentials.driver")
+	AttributeAttempt     = attribute.Key("db.credentials.attempt")
+	AttributeAuthFailure = attribute.Key("db.credentials.auth_failure")
+	AttributeStore       = attribute.Key("db.credentials.store")
+)
+
+// Config configures a Tracer or a Store.
+type Config struct {
+	// TracerProvider provides the tracer spans are created with. It defaults to the global
+	// TracerProvider.
+	TracerProvider trace.TracerProvider
+	// Attributes are added to every span.
+	Attributes []attribute.KeyValue
+}
+
+func (cfg *Config) tracer() trace.Tracer {
+	tp := otel.GetTracerProvider()
+	if cfg != nil && cfg.TracerProvider != nil {
+		tp = cfg.TracerProvider
+	}
+
+	return tp.Tracer(ScopeName)
+}
+
+func (cfg *Config) attributes() []attribute.KeyValue {
+	if cfg == nil {
+		return nil
+	}
+
+	return cfg.Attributes
+}
+
+// Tracer implements the driver.Tracer interface.
+type Tracer struct {
+	tracer     trace.Tracer
+	attributes []attribute.KeyValue
+}
+
+var _ driver.Tracer = (*Tracer)(nil)
+
+// NewTracer creates a Tracer. A nil cfg uses the defaults.
+func NewTracer(cfg *Config) *Tracer {
+	return &Tracer{
+		tracer:     cfg.tracer(),
+		attributes: cfg.attributes(),
+	}
+}
+
+// StartConnect implements the driver.Tracer interface.
+func (t *Tracer) StartConnect(ctx context.Context, info driver.ConnectInfo) (context.Context, func(error)) {
+	attrs := append([]attribute.KeyValue{
+		AttributeDriver.String(info.Driver),
+		semconv.ServerAddress(info.Host),
+		semconv.ServerPort(info.Port),
+		semconv.DBNamespace(info.DB),
+	}, t.attributes...)
+
+	ctx, span := t.tracer.Start(ctx, SpanConnect,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+
+	return ctx, func(err error) {
+		end(span, err)
+	}
+}
+
+// StartOpen implements the driver.Tracer interface.
+func (t *Tracer) StartOpen(ctx context.Context, attempt int) (context.Context, func(error, bool)) {
+	ctx, span := t.tracer.Start(ctx, SpanOpen,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(append([]attribute.KeyValue{AttributeAttempt.Int(attempt)}, t.attributes...)...),
+	)
+
+	return ctx, func(err error, authFailure bool) {
+		if err != nil {
+			span.SetAttributes(AttributeAuthFailure.Bool(authFailure))
+		}
+
+		end(span, err)
+	}
+}
+
+// Store wraps a driver.Store and creates a span for every call to it. It passes Revoke, Notify and
+// Close through to the wrapped Store when it implements driver.Revoker, driver.Notifier or io.Closer.
+type Store struct {
+	store      driver.Store
+	tracer     trace.Tracer
+	attributes []attribute.KeyValue
+}
+
+var (
+	_ driver.Store    = (*Store)(nil)
+	_ driver.Revoker  = (*Store)(nil)
+	_ driver.Notifier = (*Store)(nil)
+	_ io.Closer       = (*Store)(nil)
+)
+
+// WrapStore wraps s so calls to it are traced. A nil cfg uses the defaults.
+func WrapStore(s driver.Store, cfg *Config) *Store {
+	return &Store{
+		store:      s,
+		tracer:     cfg.tracer(),
+		attributes: append([]attribute.KeyValue{AttributeStore.String(fmt.Sprintf("%T", s))}, cfg.attributes()...),
+	}
+}
+
+// Get implements the driver.Store interface.
+func (s *Store) Get(ctx context.Context) (driver.Credentials, error) {
+	return s.call(ctx, SpanGet, s.store.Get)
+}
+
+// Refresh implements the driver.Store interface.
+func (s *Store) Refresh(ctx context.Context) (driver.Credentials, error) {
+	return s.call(ctx, SpanRefresh, s.store.Refresh)
+}
+
+// Revoke implements the driver.Revoker interface.
+func (s *Store) Revoke(ctx context.Context) error {
+	if r, ok := s.store.(driver.Revoker); ok {
+		return r.Revoke(ctx)
+	}
+
+	return nil
+}
+
+// Notify implements the driver.Notifier interface if the wrapped store does.
+func (s *Store) Notify(fn func(driver.Credentials)) func() {
+	if n, ok := s.store.(driver.Notifier); ok {
+		return n.Notify(fn)
+	}
+
+	return func() {}
+}
+
+// Close implements io.Closer.
+func (s *Store) Close() error {
+	if c, ok := s.store.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
+
+// Unwrap returns the wrapped Store.
+func (s *Store) Unwrap() driver.Store {
+	return s.store
+}
+
+func (s *Store) call(
+	ctx context.Context,
+	name string,
+	fn func(context.Context) (driver.Credentials, error),
+) (driver.Credentials, error) {
+	ctx, span := s.tracer.Start(ctx, name, trace.WithAttributes(s.attributes...))
+
+	creds, err := fn(ctx)
+	end(span, err)
+
+	return creds, err
+}
+
+// end ends span, recording err if it isn't nil.
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.SetAttributes(semconv.ErrorType(err))
+	}
+
+	span.End()
+}
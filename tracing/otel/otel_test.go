@@ -0,0 +1,189 @@
+package otel
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	credsdriver "github.com/davepgreene/go-db-credential-refresh/driver"
+	"github.com/davepgreene/go-db-credential-refresh/store"
+)
+
+const (
+	username = "username"
+	password = "password"
+)
+
+type testStore struct {
+	revoked bool
+}
+
+func (s *testStore) Get(_ context.Context) (credsdriver.Credentials, error) {
+	return &store.Credential{Username: username, Password: password}, nil
+}
+
+func (s *testStore) Refresh(_ context.Context) (credsdriver.Credentials, error) {
+	return &store.Credential{Username: username, Password: password}, nil
+}
+
+func (s *testStore) Revoke(_ context.Context) error {
+	s.revoked = true
+
+	return nil
+}
+
+type testConn struct {
+	driver.Conn
+}
+
+// testDriver rejects the first connection attempt with a MySQL authentication error.
+type testDriver struct {
+	opens int
+}
+
+func (d *testDriver) Open(_ string) (driver.Conn, error) {
+	d.opens++
+	if d.opens == 1 {
+		return nil, errors.New(credsdriver.MysqlErrorText)
+	}
+
+	return &testConn{}, nil
+}
+
+func TestTracerTracesConnectAndStore(t *testing.T) {
+	if err := credsdriver.Register("otel-test", func() *credsdriver.Driver {
+		return &credsdriver.Driver{
+			Driver:    &testDriver{},
+			Formatter: credsdriver.MysqlFormatter,
+			AuthError: credsdriver.MySQLAuthError,
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	cfg := &Config{TracerProvider: tp}
+
+	s := &testStore{}
+
+	c, err := credsdriver.NewConnector(WrapStore(s, cfg), "otel-test", &credsdriver.Config{
+		Host:    "localhost",
+		Port:    3306,
+		DB:      "test",
+		Retries: 1,
+		Tracer:  NewTracer(cfg),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !s.revoked {
+		t.Fatal("expected the wrapped store to be revoked")
+	}
+
+	spans := sr.Ended()
+
+	expected := []string{SpanGet, SpanOpen, SpanRefresh, SpanOpen, SpanConnect}
+	if len(spans) != len(expected) {
+		t.Fatalf("expected '%d' spans but got '%d' instead", len(expected), len(spans))
+	}
+
+	connect := spans[len(spans)-1]
+
+	for i, span := range spans {
+		if span.Name() != expected[i] {
+			t.Fatalf("expected '%s' but got '%s' instead", expected[i], span.Name())
+		}
+
+		for _, attr := range span.Attributes() {
+			if attr.Value.AsString() == password {
+				t.Fatalf("expected '%s' not to contain the password", span.Name())
+			}
+		}
+
+		if span.Name() != SpanConnect && span.Parent().SpanID() != connect.SpanContext().SpanID() {
+			t.Fatalf("expected '%s' to be a child of '%s'", span.Name(), SpanConnect)
+		}
+	}
+
+	if connect.Status().Code != codes.Unset {
+		t.Fatalf("expected '%v' but got '%v' instead", codes.Unset, connect.Status().Code)
+	}
+
+	failed := spans[1]
+	if failed.Status().Code != codes.Error {
+		t.Fatalf("expected '%v' but got '%v' instead", codes.Error, failed.Status().Code)
+	}
+
+	attrs := map[string]any{}
+	for _, attr := range failed.Attributes() {
+		attrs[string(attr.Key)] = attr.Value.AsInterface()
+	}
+
+	if attrs[string(AttributeAuthFailure)] != true {
+		t.Fatalf("expected '%v' but got '%v' instead", true, attrs[string(AttributeAuthFailure)])
+	}
+
+	if attrs[string(AttributeAttempt)] != int64(0) {
+		t.Fatalf("expected '%v' but got '%v' instead", 0, attrs[string(AttributeAttempt)])
+	}
+}
+
+// testNotifyingStore is a testStore that also implements driver.Notifier.
+type testNotifyingStore struct {
+	testStore
+	notify func(credsdriver.Credentials)
+}
+
+func (s *testNotifyingStore) Notify(fn func(credsdriver.Credentials)) func() {
+	s.notify = fn
+
+	return func() {
+		s.notify = nil
+	}
+}
+
+func TestStoreForwardsNotify(t *testing.T) {
+	s := &testNotifyingStore{}
+	wrapped := WrapStore(s, nil)
+
+	var received credsdriver.Credentials
+
+	stop := wrapped.Notify(func(creds credsdriver.Credentials) {
+		received = creds
+	})
+
+	if s.notify == nil {
+		t.Fatal("expected the wrapped store to be subscribed to")
+	}
+
+	creds := &store.Credential{Username: username, Password: password}
+	s.notify(creds)
+
+	if received != creds {
+		t.Fatalf("expected '%v' but got '%v' instead", creds, received)
+	}
+
+	stop()
+
+	if s.notify != nil {
+		t.Fatal("expected the wrapped store to be unsubscribed from")
+	}
+
+	// Stores that don't send credentials can still be subscribed to.
+	WrapStore(&testStore{}, nil).Notify(func(credsdriver.Credentials) {})()
+}